@@ -0,0 +1,107 @@
+package cbcolumnarotel
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// OpenTelemetryMeter is an implementation of cbcolumnar.Meter which records OpenTelemetry metrics. Counters are
+// recorded as Int64Counter instruments, and value recorders as Int64Histogram instruments with the unit "us", as
+// the SDK records them in microseconds.
+type OpenTelemetryMeter struct {
+	meter metric.Meter
+
+	lock       sync.Mutex
+	counters   map[string]metric.Int64Counter
+	histograms map[string]metric.Int64Histogram
+}
+
+var _ cbcolumnar.Meter = (*OpenTelemetryMeter)(nil)
+
+// NewOpenTelemetryMeter creates a new OpenTelemetryMeter which records metrics using the provider.
+func NewOpenTelemetryMeter(provider metric.MeterProvider) *OpenTelemetryMeter {
+	return &OpenTelemetryMeter{
+		meter:      provider.Meter(instrumentationName, metric.WithInstrumentationVersion(cbcolumnar.Version())),
+		lock:       sync.Mutex{},
+		counters:   make(map[string]metric.Int64Counter),
+		histograms: make(map[string]metric.Int64Histogram),
+	}
+}
+
+// Counter returns the counter with the given name, which records measurements with the tags as attributes.
+func (m *OpenTelemetryMeter) Counter(name string, tags map[string]string) (cbcolumnar.Counter, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	counter, ok := m.counters[name]
+	if !ok {
+		var err error
+
+		counter, err = m.meter.Int64Counter(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create counter %s: %w", name, err)
+		}
+
+		m.counters[name] = counter
+	}
+
+	return &openTelemetryCounter{
+		counter: counter,
+		attrs:   newAttributeSet(tags),
+	}, nil
+}
+
+// ValueRecorder returns the histogram with the given name, which records measurements with the tags as attributes.
+func (m *OpenTelemetryMeter) ValueRecorder(name string, tags map[string]string) (cbcolumnar.ValueRecorder, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	histogram, ok := m.histograms[name]
+	if !ok {
+		var err error
+
+		histogram, err = m.meter.Int64Histogram(name, metric.WithUnit("us"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create histogram %s: %w", name, err)
+		}
+
+		m.histograms[name] = histogram
+	}
+
+	return &openTelemetryValueRecorder{
+		histogram: histogram,
+		attrs:     newAttributeSet(tags),
+	}, nil
+}
+
+func newAttributeSet(tags map[string]string) attribute.Set {
+	attrs := make([]attribute.KeyValue, 0, len(tags))
+	for k, v := range tags {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+
+	return attribute.NewSet(attrs...)
+}
+
+type openTelemetryCounter struct {
+	counter metric.Int64Counter
+	attrs   attribute.Set
+}
+
+func (c *openTelemetryCounter) IncrementBy(num uint64) {
+	c.counter.Add(context.Background(), int64(num), metric.WithAttributeSet(c.attrs)) // #nosec G115
+}
+
+type openTelemetryValueRecorder struct {
+	histogram metric.Int64Histogram
+	attrs     attribute.Set
+}
+
+func (r *openTelemetryValueRecorder) RecordValue(val uint64) {
+	r.histogram.Record(context.Background(), int64(val), metric.WithAttributeSet(r.attrs)) // #nosec G115
+}
@@ -0,0 +1,92 @@
+package cbcolumnarotel_test
+
+import (
+	"context"
+	"testing"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/couchbase/gocbcolumnar/cbcolumnarotel"
+	"github.com/couchbase/gocbcolumnar/cbcolumnartest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func findMetric(t *testing.T, rm metricdata.ResourceMetrics, name string) metricdata.Metrics {
+	t.Helper()
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m
+			}
+		}
+	}
+
+	t.Fatalf("metric %s not found", name)
+
+	return metricdata.Metrics{}
+}
+
+func TestMeterQueryMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	srv := cbcolumnartest.NewServer()
+	t.Cleanup(srv.Close)
+
+	cluster, err := srv.NewCluster(cbcolumnar.NewClusterOptions().
+		SetMeter(cbcolumnarotel.NewOpenTelemetryMeter(provider)))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	})
+
+	srv.RegisterResponse("SELECT RAW 1", cbcolumnartest.NewResponse().SetRows([]any{1, 2, 3}))
+
+	scope := cluster.Database("db").Scope("scope")
+
+	_, _, err = cbcolumnar.ExecuteQueryAs[int](context.Background(), scope, "SELECT RAW 1")
+	require.NoError(t, err)
+
+	_, err = scope.ExecuteQuery(context.Background(), "SELECT unknown")
+	require.ErrorIs(t, err, cbcolumnar.ErrQuery)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	scopeAttrs := attribute.NewSet(attribute.String("db.name", "db"), attribute.String("db.couchbase.scope", "scope"))
+
+	duration, ok := findMetric(t, rm, "columnar.query.duration").Data.(metricdata.Histogram[int64])
+	require.True(t, ok)
+	require.Len(t, duration.DataPoints, 1)
+	assert.Equal(t, scopeAttrs, duration.DataPoints[0].Attributes)
+	assert.Equal(t, uint64(2), duration.DataPoints[0].Count)
+
+	elapsed, ok := findMetric(t, rm, "columnar.query.server_elapsed_time").Data.(metricdata.Histogram[int64])
+	require.True(t, ok)
+	require.Len(t, elapsed.DataPoints, 1)
+	assert.Equal(t, uint64(1), elapsed.DataPoints[0].Count)
+
+	rows, ok := findMetric(t, rm, "columnar.query.rows").Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, rows.DataPoints, 1)
+	assert.Equal(t, int64(3), rows.DataPoints[0].Value)
+
+	bytes, ok := findMetric(t, rm, "columnar.query.bytes").Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, bytes.DataPoints, 1)
+	assert.Equal(t, int64(3), bytes.DataPoints[0].Value)
+
+	errs, ok := findMetric(t, rm, "columnar.query.errors").Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, errs.DataPoints, 1)
+	assert.Equal(t, int64(1), errs.DataPoints[0].Value)
+
+	errorType, ok := errs.DataPoints[0].Attributes.Value("error.type")
+	require.True(t, ok)
+	assert.Equal(t, "query", errorType.AsString())
+}
@@ -0,0 +1,103 @@
+// Package cbcolumnarotel provides OpenTelemetry implementations of the observability interfaces of the
+// cbcolumnar package, allowing the operations performed by the SDK to be included in OpenTelemetry traces.
+//
+// A tracer is configured on the cluster using ClusterOptions.SetTracer:
+//
+//	opts := cbcolumnar.NewClusterOptions().
+//		SetTracer(cbcolumnarotel.NewOpenTelemetryRequestTracer(otel.GetTracerProvider()))
+//
+// Spans are created as children of the span contained within the context.Context passed to the SDK.
+package cbcolumnarotel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the instrumentation library reported to OpenTelemetry.
+const instrumentationName = "github.com/couchbase/gocbcolumnar"
+
+// OpenTelemetryRequestTracer is an implementation of cbcolumnar.RequestTracer which creates OpenTelemetry spans.
+type OpenTelemetryRequestTracer struct {
+	tracer trace.Tracer
+}
+
+var _ cbcolumnar.RequestTracer = (*OpenTelemetryRequestTracer)(nil)
+
+// NewOpenTelemetryRequestTracer creates a new OpenTelemetryRequestTracer which creates spans using the provider.
+func NewOpenTelemetryRequestTracer(provider trace.TracerProvider) *OpenTelemetryRequestTracer {
+	return &OpenTelemetryRequestTracer{
+		tracer: provider.Tracer(instrumentationName, trace.WithInstrumentationVersion(cbcolumnar.Version())),
+	}
+}
+
+// RequestSpan starts a new span as a child of the span contained within ctx, if any.
+func (t *OpenTelemetryRequestTracer) RequestSpan(ctx context.Context, name string) (context.Context, cbcolumnar.RequestSpan) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+
+	return ctx, &OpenTelemetryRequestSpan{
+		span: span,
+	}
+}
+
+// OpenTelemetryRequestSpan is an implementation of cbcolumnar.RequestSpan which wraps an OpenTelemetry span.
+type OpenTelemetryRequestSpan struct {
+	span trace.Span
+}
+
+var _ cbcolumnar.RequestSpan = (*OpenTelemetryRequestSpan)(nil)
+
+// Span returns the underlying OpenTelemetry span.
+func (s *OpenTelemetryRequestSpan) Span() trace.Span {
+	return s.span
+}
+
+// SetAttribute sets an attribute on the span.
+func (s *OpenTelemetryRequestSpan) SetAttribute(key string, value interface{}) {
+	s.span.SetAttributes(newAttribute(key, value))
+}
+
+// AddEvent records an event on the span.
+func (s *OpenTelemetryRequestSpan) AddEvent(name string, timestamp time.Time) {
+	s.span.AddEvent(name, trace.WithTimestamp(timestamp))
+}
+
+// RecordError records the error on the span and marks the span as failed.
+func (s *OpenTelemetryRequestSpan) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End completes the span.
+func (s *OpenTelemetryRequestSpan) End() {
+	s.span.End()
+}
+
+// newAttribute converts the attribute value to its OpenTelemetry representation. Durations are recorded in
+// microseconds.
+func newAttribute(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case uint64:
+		return attribute.Int64(key, int64(v)) // #nosec G115
+	case float64:
+		return attribute.Float64(key, v)
+	case time.Duration:
+		return attribute.Int64(key, v.Microseconds())
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
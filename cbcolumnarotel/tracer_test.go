@@ -0,0 +1,135 @@
+package cbcolumnarotel_test
+
+import (
+	"context"
+	"testing"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/couchbase/gocbcolumnar/cbcolumnarotel"
+	"github.com/couchbase/gocbcolumnar/cbcolumnartest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTracedCluster(t *testing.T) (*cbcolumnartest.Server, *cbcolumnar.Cluster, *tracetest.SpanRecorder,
+	*sdktrace.TracerProvider) {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	srv := cbcolumnartest.NewServer()
+	t.Cleanup(srv.Close)
+
+	cluster, err := srv.NewCluster(cbcolumnar.NewClusterOptions().
+		SetTracer(cbcolumnarotel.NewOpenTelemetryRequestTracer(provider)))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	})
+
+	return srv, cluster, recorder, provider
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+
+	return attrs
+}
+
+func TestTracerQuerySpan(t *testing.T) {
+	srv, cluster, recorder, provider := newTracedCluster(t)
+
+	srv.RegisterResponse("SELECT RAW 1", cbcolumnartest.NewResponse().SetRows([]any{1, 2, 3}))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+
+	scope := cluster.Database("db").Scope("scope")
+
+	rows, _, err := cbcolumnar.ExecuteQueryAs[int](ctx, scope, "SELECT RAW 1",
+		cbcolumnar.NewQueryOptions().SetClientContextID("my-context-id"))
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, rows)
+
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	dispatch, query := spans[0], spans[1]
+
+	assert.Equal(t, "dispatch_to_server", dispatch.Name())
+	assert.Equal(t, query.SpanContext().SpanID(), dispatch.Parent().SpanID())
+	assert.Equal(t, int64(200), spanAttributes(dispatch)["http.response.status_code"].AsInt64())
+
+	assert.Equal(t, "query", query.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), query.Parent().SpanID())
+	assert.Equal(t, parent.SpanContext().TraceID(), query.SpanContext().TraceID())
+
+	attrs := spanAttributes(query)
+	assert.Equal(t, "couchbase", attrs["db.system"].AsString())
+	assert.Equal(t, "analytics", attrs["db.couchbase.service"].AsString())
+	assert.Equal(t, "SELECT RAW 1", attrs["db.statement"].AsString())
+	assert.Equal(t, "db", attrs["db.name"].AsString())
+	assert.Equal(t, "scope", attrs["db.couchbase.scope"].AsString())
+	assert.Equal(t, "my-context-id", attrs["db.couchbase.client_context_id"].AsString())
+	assert.Equal(t, int64(3), attrs["db.couchbase.row_count"].AsInt64())
+	assert.Contains(t, attrs, attribute.Key("db.couchbase.server_duration"))
+	assert.Equal(t, codes.Unset, query.Status().Code)
+}
+
+func TestTracerQuerySpanRedactsStatement(t *testing.T) {
+	srv, cluster, recorder, _ := newTracedCluster(t)
+
+	srv.RegisterResponse("SELECT RAW 1", cbcolumnartest.NewResponse().SetRows([]any{1}))
+
+	cbcolumnar.SetLogRedactionLevel(cbcolumnar.RedactPartial)
+	defer cbcolumnar.SetLogRedactionLevel(cbcolumnar.RedactNone)
+
+	_, _, err := cbcolumnar.ExecuteQueryAs[int](context.Background(), cluster, "SELECT RAW 1")
+	require.NoError(t, err)
+
+	cbcolumnar.SetLogRedactionLevel(cbcolumnar.RedactFull)
+
+	_, _, err = cbcolumnar.ExecuteQueryAs[int](context.Background(), cluster, "SELECT RAW 1")
+	require.NoError(t, err)
+
+	var queries []sdktrace.ReadOnlySpan
+
+	for _, span := range recorder.Ended() {
+		if span.Name() == "query" {
+			queries = append(queries, span)
+		}
+	}
+
+	require.Len(t, queries, 2)
+	assert.Equal(t, "<ud>SELECT RAW 1</ud>", spanAttributes(queries[0])["db.statement"].AsString())
+	assert.NotContains(t, spanAttributes(queries[1]), attribute.Key("db.statement"))
+}
+
+func TestTracerQuerySpanError(t *testing.T) {
+	_, cluster, recorder, _ := newTracedCluster(t)
+
+	_, err := cluster.ExecuteQuery(context.Background(), "SELECT unknown")
+	require.Error(t, err)
+
+	var query sdktrace.ReadOnlySpan
+
+	for _, span := range recorder.Ended() {
+		if span.Name() == "query" {
+			query = span
+		}
+	}
+
+	require.NotNil(t, query)
+	assert.Equal(t, codes.Error, query.Status().Code)
+	assert.NotEmpty(t, query.Events())
+}
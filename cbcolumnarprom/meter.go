@@ -0,0 +1,206 @@
+// Package cbcolumnarprom provides a Prometheus implementation of cbcolumnar.Meter, allowing the metrics
+// recorded by the SDK to be scraped by Prometheus.
+//
+// A meter is configured on the cluster using ClusterOptions.SetMeter:
+//
+//	opts := cbcolumnar.NewClusterOptions().
+//		SetMeter(cbcolumnarprom.NewPrometheusMeter(prometheus.DefaultRegisterer))
+//
+// The names of metrics and tags are converted to valid Prometheus names by replacing any invalid characters
+// with underscores, such that columnar.query.rows becomes columnar_query_rows_total and db.name becomes db_name.
+package cbcolumnarprom
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultBuckets are the histogram buckets used by NewPrometheusMeter, in microseconds, ranging from 1ms to
+// approximately 9 minutes.
+var DefaultBuckets = prometheus.ExponentialBuckets(1000, 2, 20)
+
+// PrometheusMeter is an implementation of cbcolumnar.Meter which records Prometheus metrics. Counters are
+// recorded as counters with a _total suffix, and value recorders as histograms with a _microseconds suffix. The
+// values are recorded in microseconds, the unit in which the SDK records them, without being converted.
+type PrometheusMeter struct {
+	registerer prometheus.Registerer
+	buckets    []float64
+
+	lock       sync.Mutex
+	counters   map[string]*prometheus.CounterVec
+	histograms map[string]*prometheus.HistogramVec
+}
+
+var _ cbcolumnar.Meter = (*PrometheusMeter)(nil)
+
+// NewPrometheusMeter creates a new PrometheusMeter which registers its metrics with the registerer, using
+// DefaultBuckets for histograms.
+func NewPrometheusMeter(registerer prometheus.Registerer) *PrometheusMeter {
+	return NewPrometheusMeterWithBuckets(registerer, DefaultBuckets)
+}
+
+// NewPrometheusMeterWithBuckets creates a new PrometheusMeter which registers its metrics with the registerer,
+// using the buckets, in microseconds, for histograms.
+func NewPrometheusMeterWithBuckets(registerer prometheus.Registerer, buckets []float64) *PrometheusMeter {
+	return &PrometheusMeter{
+		registerer: registerer,
+		buckets:    buckets,
+		lock:       sync.Mutex{},
+		counters:   make(map[string]*prometheus.CounterVec),
+		histograms: make(map[string]*prometheus.HistogramVec),
+	}
+}
+
+// Counter returns the counter with the given name and tags. The tags must have the same keys each time the
+// counter with the name is requested.
+func (m *PrometheusMeter) Counter(name string, tags map[string]string) (cbcolumnar.Counter, error) {
+	labels := newLabels(tags)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	vec, ok := m.counters[name]
+	if !ok {
+		vec = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   "",
+			Subsystem:   "",
+			Name:        sanitizeName(name) + "_total",
+			Help:        fmt.Sprintf("The total of %s recorded by the Couchbase Columnar SDK.", name),
+			ConstLabels: nil,
+		}, labelNames(labels))
+
+		registered, err := register(m.registerer, vec)
+		if err != nil {
+			return nil, err
+		}
+
+		vec = registered
+		m.counters[name] = vec
+	}
+
+	counter, err := vec.GetMetricWith(labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get counter %s: %w", name, err)
+	}
+
+	return &prometheusCounter{
+		counter: counter,
+	}, nil
+}
+
+// ValueRecorder returns the histogram with the given name and tags. The tags must have the same keys each time
+// the histogram with the name is requested.
+func (m *PrometheusMeter) ValueRecorder(name string, tags map[string]string) (cbcolumnar.ValueRecorder, error) {
+	labels := newLabels(tags)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	vec, ok := m.histograms[name]
+	if !ok {
+		vec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:                       "",
+			Subsystem:                       "",
+			Name:                            sanitizeName(name) + "_microseconds",
+			Help:                            fmt.Sprintf("The distribution of %s recorded by the Couchbase Columnar SDK.", name),
+			ConstLabels:                     nil,
+			Buckets:                         m.buckets,
+			NativeHistogramBucketFactor:     0,
+			NativeHistogramZeroThreshold:    0,
+			NativeHistogramMaxBucketNumber:  0,
+			NativeHistogramMinResetDuration: 0,
+			NativeHistogramMaxZeroThreshold: 0,
+			NativeHistogramMaxExemplars:     0,
+			NativeHistogramExemplarTTL:      0,
+		}, labelNames(labels))
+
+		registered, err := register(m.registerer, vec)
+		if err != nil {
+			return nil, err
+		}
+
+		vec = registered
+		m.histograms[name] = vec
+	}
+
+	observer, err := vec.GetMetricWith(labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get histogram %s: %w", name, err)
+	}
+
+	return &prometheusValueRecorder{
+		observer: observer,
+	}, nil
+}
+
+// register registers the collector, returning the existing collector if an identical one is already registered,
+// such as by another PrometheusMeter using the same registerer.
+func register[T prometheus.Collector](registerer prometheus.Registerer, collector T) (T, error) {
+	err := registerer.Register(collector)
+	if err == nil {
+		return collector, nil
+	}
+
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if errors.As(err, &alreadyRegistered) {
+		if existing, ok := alreadyRegistered.ExistingCollector.(T); ok {
+			return existing, nil
+		}
+	}
+
+	return collector, fmt.Errorf("failed to register metric: %w", err)
+}
+
+func newLabels(tags map[string]string) prometheus.Labels {
+	labels := make(prometheus.Labels, len(tags))
+	for k, v := range tags {
+		labels[sanitizeName(k)] = v
+	}
+
+	return labels
+}
+
+func labelNames(labels prometheus.Labels) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// sanitizeName replaces any characters which are not valid in Prometheus metric and label names with underscores.
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+
+		return '_'
+	}, name)
+}
+
+type prometheusCounter struct {
+	counter prometheus.Counter
+}
+
+func (c *prometheusCounter) IncrementBy(num uint64) {
+	c.counter.Add(float64(num))
+}
+
+type prometheusValueRecorder struct {
+	observer prometheus.Observer
+}
+
+// RecordValue records the value, in microseconds.
+func (r *prometheusValueRecorder) RecordValue(val uint64) {
+	r.observer.Observe(float64(val))
+}
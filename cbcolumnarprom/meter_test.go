@@ -0,0 +1,104 @@
+package cbcolumnarprom_test
+
+import (
+	"context"
+	"testing"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/couchbase/gocbcolumnar/cbcolumnarprom"
+	"github.com/couchbase/gocbcolumnar/cbcolumnartest"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findMetricFamily(t *testing.T, families []*dto.MetricFamily, name string) *dto.MetricFamily {
+	t.Helper()
+
+	for _, family := range families {
+		if family.GetName() == name {
+			return family
+		}
+	}
+
+	t.Fatalf("metric %s not found", name)
+
+	return nil
+}
+
+func labelValue(metric *dto.Metric, name string) string {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+
+	return ""
+}
+
+func TestPrometheusMeterQueryMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	srv := cbcolumnartest.NewServer()
+	t.Cleanup(srv.Close)
+
+	cluster, err := srv.NewCluster(cbcolumnar.NewClusterOptions().
+		SetMeter(cbcolumnarprom.NewPrometheusMeter(registry)))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	})
+
+	srv.RegisterResponse("SELECT RAW 1", cbcolumnartest.NewResponse().SetRows([]any{1, 2, 3}))
+
+	_, _, err = cbcolumnar.ExecuteQueryAs[int](context.Background(), cluster, "SELECT RAW 1")
+	require.NoError(t, err)
+
+	_, err = cluster.Database("db").Scope("scope").ExecuteQuery(context.Background(), "SELECT unknown")
+	require.ErrorIs(t, err, cbcolumnar.ErrQuery)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	duration := findMetricFamily(t, families, "columnar_query_duration_microseconds")
+	assert.Equal(t, dto.MetricType_HISTOGRAM, duration.GetType())
+	require.Len(t, duration.GetMetric(), 2)
+
+	rows := findMetricFamily(t, families, "columnar_query_rows_total")
+	require.Len(t, rows.GetMetric(), 1)
+	assert.InDelta(t, 3, rows.GetMetric()[0].GetCounter().GetValue(), 0)
+	assert.Equal(t, "", labelValue(rows.GetMetric()[0], "db_name"))
+
+	errs := findMetricFamily(t, families, "columnar_query_errors_total")
+	require.Len(t, errs.GetMetric(), 1)
+	assert.InDelta(t, 1, errs.GetMetric()[0].GetCounter().GetValue(), 0)
+	assert.Equal(t, "db", labelValue(errs.GetMetric()[0], "db_name"))
+	assert.Equal(t, "scope", labelValue(errs.GetMetric()[0], "db_couchbase_scope"))
+	assert.Equal(t, "query", labelValue(errs.GetMetric()[0], "error_type"))
+}
+
+func TestPrometheusMeterSharedRegisterer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	first := cbcolumnarprom.NewPrometheusMeter(registry)
+	second := cbcolumnarprom.NewPrometheusMeter(registry)
+
+	tags := map[string]string{"db.name": "db"}
+
+	counter, err := first.Counter("columnar.query.rows", tags)
+	require.NoError(t, err)
+	counter.IncrementBy(1)
+
+	counter, err = second.Counter("columnar.query.rows", tags)
+	require.NoError(t, err)
+	counter.IncrementBy(2)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	rows := findMetricFamily(t, families, "columnar_query_rows_total")
+	require.Len(t, rows.GetMetric(), 1)
+	assert.InDelta(t, 3, rows.GetMetric()[0].GetCounter().GetValue(), 0)
+}
@@ -0,0 +1,112 @@
+package cbcolumnartest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/couchbase/gocbcolumnar/cbcolumnartest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cachingCredentialProvider only picks up a new password when it is refreshed.
+type cachingCredentialProvider struct {
+	lock      sync.Mutex
+	current   string
+	next      string
+	refreshes int
+}
+
+func (p *cachingCredentialProvider) Credential(_ context.Context) (cbcolumnar.Credential, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return cbcolumnar.NewCredential(cbcolumnartest.Username, p.current), nil
+}
+
+func (p *cachingCredentialProvider) Refresh(_ context.Context) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.current = p.next
+	p.refreshes++
+
+	return nil
+}
+
+func TestServerCredentialRefreshOnUnauthorized(t *testing.T) {
+	srv := cbcolumnartest.NewServer()
+	defer srv.Close()
+
+	srv.RegisterResponse("SELECT RAW 1", cbcolumnartest.NewResponse().SetRows([]any{1}))
+
+	provider := &cachingCredentialProvider{
+		lock:      sync.Mutex{},
+		current:   cbcolumnartest.Password,
+		next:      cbcolumnartest.Password,
+		refreshes: 0,
+	}
+
+	cluster, err := srv.NewClusterWithCredentialProvider(provider)
+	require.NoError(t, err)
+	defer func() {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}()
+
+	_, err = cbcolumnar.ExecuteQueryOne[int](context.Background(), cluster, "SELECT RAW 1")
+	require.NoError(t, err)
+
+	srv.SetCredential(cbcolumnartest.Username, "rotated")
+
+	// The provider returns the same credential after refreshing, so the query is not retried.
+	_, err = cbcolumnar.ExecuteQueryOne[int](context.Background(), cluster, "SELECT RAW 1")
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidCredential)
+	assert.Equal(t, 1, provider.refreshes)
+
+	provider.lock.Lock()
+	provider.next = "rotated"
+	provider.lock.Unlock()
+
+	row, err := cbcolumnar.ExecuteQueryOne[int](context.Background(), cluster, "SELECT RAW 1")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, row)
+	assert.Equal(t, 2, provider.refreshes)
+	assert.Len(t, srv.Requests(), 2)
+}
+
+func TestServerFileCredentialRotation(t *testing.T) {
+	srv := cbcolumnartest.NewServer()
+	defer srv.Close()
+
+	srv.RegisterResponse("SELECT RAW 1", cbcolumnartest.NewResponse().SetRows([]any{1}))
+
+	dir := t.TempDir()
+	usernamePath := filepath.Join(dir, "username")
+	passwordPath := filepath.Join(dir, "password")
+
+	require.NoError(t, os.WriteFile(usernamePath, []byte(cbcolumnartest.Username+"\n"), 0o600))
+	require.NoError(t, os.WriteFile(passwordPath, []byte(cbcolumnartest.Password+"\n"), 0o600))
+
+	cluster, err := srv.NewClusterWithCredentialProvider(
+		cbcolumnar.NewFileCredentialProvider(usernamePath, passwordPath))
+	require.NoError(t, err)
+	defer func() {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}()
+
+	_, err = cbcolumnar.ExecuteQueryOne[int](context.Background(), cluster, "SELECT RAW 1")
+	require.NoError(t, err)
+
+	srv.SetCredential(cbcolumnartest.Username, "a-rotated-password")
+	require.NoError(t, os.WriteFile(passwordPath, []byte("a-rotated-password\n"), 0o600))
+
+	_, err = cbcolumnar.ExecuteQueryOne[int](context.Background(), cluster, "SELECT RAW 1")
+	require.NoError(t, err)
+}
@@ -0,0 +1,193 @@
+package cbcolumnartest_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/couchbase/gocbcolumnar/cbcolumnartest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerPing(t *testing.T) {
+	srv, cluster := newTestCluster(t)
+
+	report, err := cluster.Ping(context.Background())
+	require.NoError(t, err)
+
+	assert.True(t, report.Ok())
+	assert.NotEmpty(t, report.ID)
+	require.Len(t, report.Endpoints, 1)
+
+	endpoint := report.Endpoints[0]
+	assert.Equal(t, srv.URL(), endpoint.Endpoint)
+	assert.Equal(t, cbcolumnar.PingStateOk, endpoint.State)
+	assert.Equal(t, 200, endpoint.StatusCode)
+	assert.Positive(t, endpoint.Latency)
+	require.NoError(t, endpoint.Error)
+	require.NotNil(t, endpoint.TLS)
+	assert.NotEmpty(t, endpoint.TLS.Version)
+	assert.NotEmpty(t, endpoint.TLS.CipherSuite)
+	assert.Equal(t, srv.Certificate().NotAfter, endpoint.TLS.PeerCertificateExpiry)
+
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	endpoints, ok := decoded["endpoints"].([]any)
+	require.True(t, ok)
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, "ok", endpoints[0].(map[string]any)["state"])
+	assert.Contains(t, endpoints[0], "latency_us")
+}
+
+func TestServerWaitUntilReady(t *testing.T) {
+	_, cluster := newTestCluster(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := cluster.WaitUntilReady(ctx)
+	require.NoError(t, err)
+}
+
+func TestServerWaitUntilReadyInvalidCredential(t *testing.T) {
+	srv, cluster := newTestCluster(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The cluster must have bootstrapped before the credential is rotated, otherwise the agent fails to connect.
+	err := cluster.WaitUntilReady(ctx)
+	require.NoError(t, err)
+
+	srv.SetCredential(cbcolumnartest.Username, "rotated")
+
+	err = cluster.WaitUntilReady(ctx)
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidCredential)
+
+	report, err := cluster.Ping(ctx)
+	require.NoError(t, err)
+
+	assert.False(t, report.Ok())
+	assert.Equal(t, cbcolumnar.PingStateError, report.Endpoints[0].State)
+	assert.Equal(t, 401, report.Endpoints[0].StatusCode)
+}
+
+func TestServerWaitUntilReadyUnreachable(t *testing.T) {
+	srv := cbcolumnartest.NewServer()
+
+	cluster, err := srv.NewCluster()
+	require.NoError(t, err)
+	defer func() {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}()
+
+	srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	err = cluster.WaitUntilReady(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestServerDiagnostics(t *testing.T) {
+	srv, cluster := newTestCluster(t)
+
+	// Before anything has been sent only the address from the connection string is known.
+	report, err := cluster.Diagnostics(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, report.Endpoints, 1)
+	assert.Equal(t, srv.ConnectionString(), report.Endpoints[0].Endpoint)
+	assert.Equal(t, cbcolumnar.EndpointSourceConfigured, report.Endpoints[0].Source)
+	assert.Equal(t, []string{"127.0.0.1"}, report.Endpoints[0].ResolvedAddresses)
+	assert.Empty(t, report.Endpoints[0].Connections)
+
+	_, err = cluster.Ping(context.Background())
+	require.NoError(t, err)
+
+	report, err = cluster.Diagnostics(context.Background())
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, report.ID)
+	require.Len(t, report.Endpoints, 2)
+	assert.Equal(t, srv.ConnectionString(), report.Endpoints[0].Endpoint)
+
+	endpoint := report.Endpoints[1]
+	assert.Equal(t, srv.URL(), endpoint.Endpoint)
+	assert.Equal(t, cbcolumnar.EndpointSourceClusterConfig, endpoint.Source)
+	assert.Equal(t, []string{"127.0.0.1"}, endpoint.ResolvedAddresses)
+	require.NoError(t, endpoint.ResolveError)
+	require.Len(t, endpoint.Connections, 1)
+
+	conn := endpoint.Connections[0]
+	assert.Equal(t, srv.URL(), conn.Endpoint)
+	assert.Equal(t, cbcolumnar.ConnectionStateIdle, conn.State)
+	assert.NotEmpty(t, conn.LocalAddress)
+	assert.Equal(t, srv.URL(), "https://"+conn.RemoteAddress)
+	assert.False(t, conn.ConnectedAt.IsZero())
+	assert.False(t, conn.LastActivity.Before(conn.ConnectedAt))
+
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	endpoints, ok := decoded["endpoints"].([]any)
+	require.True(t, ok)
+	require.Len(t, endpoints, 2)
+	assert.Equal(t, "configured", endpoints[0].(map[string]any)["source"])
+	assert.Equal(t, "cluster_config", endpoints[1].(map[string]any)["source"])
+
+	conns, ok := endpoints[1].(map[string]any)["connections"].([]any)
+	require.True(t, ok)
+	require.Len(t, conns, 1)
+	assert.Equal(t, "idle", conns[0].(map[string]any)["state"])
+}
+
+func TestServerDiagnosticsQueryConnection(t *testing.T) {
+	srv, cluster := newTestCluster(t)
+
+	srv.RegisterResponse("SELECT 1", cbcolumnartest.NewResponse().SetRows([]any{1}))
+
+	result, err := cluster.ExecuteQuery(context.Background(), "SELECT 1")
+	require.NoError(t, err)
+
+	queryConn := func() cbcolumnar.ConnectionDiagnostics {
+		report, err := cluster.Diagnostics(context.Background())
+		require.NoError(t, err)
+
+		for _, endpoint := range report.Endpoints {
+			if endpoint.Endpoint == srv.URL() {
+				require.Len(t, endpoint.Connections, 1)
+
+				return endpoint.Connections[0]
+			}
+		}
+
+		require.Fail(t, "analytics endpoint not reported")
+
+		return cbcolumnar.ConnectionDiagnostics{}
+	}
+
+	// The connection used by the query is active until all of its rows have been read.
+	conn := queryConn()
+	assert.Equal(t, cbcolumnar.ConnectionStateActive, conn.State)
+	assert.Equal(t, srv.URL(), "https://"+conn.RemoteAddress)
+
+	for row := result.NextRow(); row != nil; row = result.NextRow() { // nolint: revive
+	}
+
+	require.NoError(t, result.Err())
+
+	assert.Equal(t, cbcolumnar.ConnectionStateIdle, queryConn().State)
+}
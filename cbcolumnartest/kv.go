@@ -0,0 +1,240 @@
+package cbcolumnartest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+)
+
+const (
+	kvMagicRequest  = 0x80
+	kvMagicResponse = 0x81
+
+	kvHeaderSize = 24
+
+	kvOpHello            = 0x1f
+	kvOpSASLListMechs    = 0x20
+	kvOpSASLAuth         = 0x21
+	kvOpGetClusterConfig = 0xb5
+
+	kvStatusSuccess        = 0x00
+	kvStatusAuthError      = 0x20
+	kvStatusAccessError    = 0x24
+	kvStatusUnknownCommand = 0x81
+)
+
+// kvServer implements just enough of the memcached binary protocol spoken by the key-value service of a node
+// for gocbcore to bootstrap against it: HELLO, PLAIN SASL authentication and fetching the cluster config.
+// Every other command is rejected as unknown.
+type kvServer struct {
+	listener net.Listener
+
+	// authenticate reports whether the username and password sent via SASL are valid.
+	authenticate func(username, password string) bool
+
+	// authenticateCertificate reports whether the client certificates presented during the TLS handshake
+	// authenticate the connection without SASL.
+	authenticateCertificate func(state tls.ConnectionState) bool
+
+	// clusterConfig returns the cluster config to send to the client.
+	clusterConfig func() []byte
+
+	lock   sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func newKVServer(tlsConfig *tls.Config, authenticate func(username, password string) bool,
+	authenticateCertificate func(state tls.ConnectionState) bool, clusterConfig func() []byte) (*kvServer, error) {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	if err != nil {
+		return nil, err // nolint: wrapcheck
+	}
+
+	s := &kvServer{
+		listener:                listener,
+		authenticate:            authenticate,
+		authenticateCertificate: authenticateCertificate,
+		clusterConfig:           clusterConfig,
+		lock:                    sync.Mutex{},
+		conns:                   make(map[net.Conn]struct{}),
+		closed:                  false,
+		wg:                      sync.WaitGroup{},
+	}
+
+	s.wg.Add(1)
+
+	go s.accept()
+
+	return s, nil
+}
+
+// Port returns the port that the server is listening on.
+func (s *kvServer) Port() int {
+	addr, _ := s.listener.Addr().(*net.TCPAddr)
+
+	return addr.Port
+}
+
+// Close stops the server, closing all connections and blocking until they have been cleaned up.
+func (s *kvServer) Close() {
+	s.lock.Lock()
+	s.closed = true
+
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.lock.Unlock()
+
+	_ = s.listener.Close()
+
+	s.wg.Wait()
+}
+
+func (s *kvServer) accept() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.lock.Lock()
+		if s.closed {
+			s.lock.Unlock()
+
+			_ = conn.Close()
+
+			return
+		}
+
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.lock.Unlock()
+
+		go s.serve(conn)
+	}
+}
+
+func (s *kvServer) serve(conn net.Conn) {
+	defer s.wg.Done()
+
+	defer func() {
+		s.lock.Lock()
+		delete(s.conns, conn)
+		s.lock.Unlock()
+
+		_ = conn.Close()
+	}()
+
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return
+	}
+
+	err := tlsConn.Handshake()
+	if err != nil {
+		return
+	}
+
+	authenticated := s.authenticateCertificate(tlsConn.ConnectionState())
+	reader := bufio.NewReader(conn)
+
+	for {
+		header := make([]byte, kvHeaderSize)
+
+		_, err := io.ReadFull(reader, header)
+		if err != nil {
+			return
+		}
+
+		if header[0] != kvMagicRequest {
+			return
+		}
+
+		opcode := header[1]
+		keyLen := int(binary.BigEndian.Uint16(header[2:4]))
+		extrasLen := int(header[4])
+		body := make([]byte, binary.BigEndian.Uint32(header[8:12]))
+		opaque := header[12:16]
+
+		_, err = io.ReadFull(reader, body)
+		if err != nil {
+			return
+		}
+
+		if keyLen+extrasLen > len(body) {
+			return
+		}
+
+		value := body[extrasLen+keyLen:]
+
+		status := uint16(kvStatusSuccess)
+
+		var respValue []byte
+
+		switch opcode {
+		case kvOpHello:
+			// No features are supported, so the response lists none.
+		case kvOpSASLListMechs:
+			respValue = []byte("PLAIN")
+		case kvOpSASLAuth:
+			username, password, err := parsePlainAuth(value)
+			if err != nil || !s.authenticate(username, password) {
+				status = kvStatusAuthError
+				respValue = []byte("Auth failure")
+
+				break
+			}
+
+			authenticated = true
+		case kvOpGetClusterConfig:
+			if !authenticated {
+				status = kvStatusAccessError
+
+				break
+			}
+
+			respValue = s.clusterConfig()
+		default:
+			status = kvStatusUnknownCommand
+		}
+
+		err = writeKVResponse(conn, opcode, status, opaque, respValue)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// parsePlainAuth parses the username and password from the value of a PLAIN SASL_AUTH request, of the form
+// authzid\x00username\x00password.
+func parsePlainAuth(value []byte) (string, string, error) {
+	parts := bytes.Split(value, []byte{0})
+	if len(parts) != 3 {
+		return "", "", errors.New("invalid PLAIN auth value")
+	}
+
+	return string(parts[1]), string(parts[2]), nil
+}
+
+func writeKVResponse(w io.Writer, opcode byte, status uint16, opaque, value []byte) error {
+	packet := make([]byte, kvHeaderSize+len(value))
+	packet[0] = kvMagicResponse
+	packet[1] = opcode
+	binary.BigEndian.PutUint16(packet[6:8], status)
+	binary.BigEndian.PutUint32(packet[8:12], uint32(len(value)))
+	copy(packet[12:16], opaque)
+	copy(packet[kvHeaderSize:], value)
+
+	_, err := w.Write(packet)
+
+	return err // nolint: wrapcheck
+}
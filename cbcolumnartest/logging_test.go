@@ -0,0 +1,78 @@
+package cbcolumnartest_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/couchbase/gocbcolumnar/cbcolumnartest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type capturingLogger struct {
+	lock     sync.Mutex
+	messages []string
+}
+
+func (l *capturingLogger) Log(_ cbcolumnar.LogLevel, _ int, format string, v ...interface{}) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+
+	return nil
+}
+
+func (l *capturingLogger) Find(prefix string) string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for _, msg := range l.messages {
+		if strings.HasPrefix(msg, prefix) {
+			return msg
+		}
+	}
+
+	return ""
+}
+
+func TestServerClusterLogger(t *testing.T) {
+	prodLogger := &capturingLogger{}
+	sandboxLogger := &capturingLogger{}
+
+	prodSrv, prodCluster := newTestCluster(t, cbcolumnar.NewClusterOptions().
+		SetLogger(prodLogger).
+		SetClusterID("prod"))
+	sandboxSrv, sandboxCluster := newTestCluster(t, cbcolumnar.NewClusterOptions().
+		SetLogger(sandboxLogger).
+		SetClusterID("sandbox"))
+
+	prodSrv.RegisterResponse("SELECT 1", cbcolumnartest.NewResponse().SetRows([]any{1}))
+	sandboxSrv.RegisterResponse("SELECT 1", cbcolumnartest.NewResponse().SetRows([]any{1}))
+
+	_, _, err := cbcolumnar.ExecuteQueryAs[int](context.Background(), prodCluster, "SELECT 1",
+		cbcolumnar.NewQueryOptions().SetClientContextID("prod-query"))
+	require.NoError(t, err)
+
+	_, _, err = cbcolumnar.ExecuteQueryAs[int](context.Background(), sandboxCluster, "SELECT 1",
+		cbcolumnar.NewQueryOptions().SetClientContextID("sandbox-query"))
+	require.NoError(t, err)
+
+	// Each cluster logs only to its own logger, with every message tagged with the cluster ID.
+	assert.Equal(t, "Dispatching query cluster_id=prod client_context_id=prod-query",
+		prodLogger.Find("Dispatching query"))
+	assert.Equal(t, "Dispatching query cluster_id=sandbox client_context_id=sandbox-query",
+		sandboxLogger.Find("Dispatching query"))
+
+	prodLogger.lock.Lock()
+	defer prodLogger.lock.Unlock()
+
+	for _, msg := range prodLogger.messages {
+		assert.Contains(t, msg, "cluster_id=prod")
+		assert.NotContains(t, msg, "sandbox")
+	}
+}
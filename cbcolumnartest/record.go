@@ -0,0 +1,73 @@
+package cbcolumnartest
+
+import (
+	"fmt"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/couchbase/gocbcolumnar/internal/hooks"
+)
+
+// NewRecordingCluster creates a *cbcolumnar.Cluster connected to the cluster at connStr which records every query
+// executed via ExecuteQuery, including the request, the rows, the meta-data and any errors.
+// The recording is written to the golden file at path when the Cluster is closed, and can be replayed
+// using NewReplayCluster. Queries submitted via StartQuery are not recorded.
+func NewRecordingCluster(connStr string, credential cbcolumnar.Credential, path string,
+	opts ...*cbcolumnar.ClusterOptions) (*cbcolumnar.Cluster, error) {
+	recordOpts := &cbcolumnar.ClusterOptions{
+		TimeoutOptions:          nil,
+		SecurityOptions:         nil,
+		SrvOptions:              nil,
+		ThresholdLoggingOptions: nil,
+		Unmarshaler:             nil,
+		Tracer:                  nil,
+		Meter:                   nil,
+		Logger:                  nil,
+		LogRedactionLevel:       nil,
+		ClusterID:               "",
+	}
+	hooks.SetRecordPath(recordOpts, path)
+
+	clusterOpts := make([]*cbcolumnar.ClusterOptions, 0, len(opts)+1)
+	clusterOpts = append(clusterOpts, opts...)
+	clusterOpts = append(clusterOpts, recordOpts)
+
+	cluster, err := cbcolumnar.NewCluster(connStr, credential, clusterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cluster: %w", err)
+	}
+
+	return cluster, nil
+}
+
+// NewReplayCluster creates a *cbcolumnar.Cluster which replays the queries recorded in the golden file at path,
+// without connecting to a cluster.
+// Each query executed is matched against the recorded queries by its statement and options, excluding the client
+// context ID and timeout. Each recorded query is replayed at most once, in the order they were recorded.
+// If no recorded query matches then an error is returned.
+func NewReplayCluster(path string, opts ...*cbcolumnar.ClusterOptions) (*cbcolumnar.Cluster, error) {
+	replayOpts := &cbcolumnar.ClusterOptions{
+		TimeoutOptions:          nil,
+		SecurityOptions:         nil,
+		SrvOptions:              nil,
+		ThresholdLoggingOptions: nil,
+		Unmarshaler:             nil,
+		Tracer:                  nil,
+		Meter:                   nil,
+		Logger:                  nil,
+		LogRedactionLevel:       nil,
+		ClusterID:               "",
+	}
+	hooks.SetReplayPath(replayOpts, path)
+
+	clusterOpts := make([]*cbcolumnar.ClusterOptions, 0, len(opts)+1)
+	clusterOpts = append(clusterOpts, opts...)
+	clusterOpts = append(clusterOpts, replayOpts)
+
+	cluster, err := cbcolumnar.NewCluster("couchbases://localhost?srv=false", cbcolumnar.NewCredential("", ""),
+		clusterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cluster: %w", err)
+	}
+
+	return cluster, nil
+}
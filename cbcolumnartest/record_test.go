@@ -0,0 +1,88 @@
+package cbcolumnartest_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/couchbase/gocbcolumnar/cbcolumnartest"
+	"github.com/couchbase/gocbcolumnar/internal/hooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden.json")
+
+	srv := cbcolumnartest.NewServer()
+	defer srv.Close()
+
+	srv.RegisterResponse("SELECT RAW $1", cbcolumnartest.NewResponse().SetRows([]any{1, 2, 3}))
+	srv.RegisterResponse("SELECT RAW 1", cbcolumnartest.NewResponse().
+		SetRows([]any{1}).
+		SetErrors([]cbcolumnartest.Error{{Code: 23000, Message: "Internal error", Retriable: false}}).
+		SetStatusCode(200))
+	srv.RegisterResponse("SELEC 1", cbcolumnartest.NewResponse().
+		SetErrors([]cbcolumnartest.Error{{Code: 24000, Message: "Syntax error", Retriable: false}}))
+
+	recordOpts := cbcolumnar.NewClusterOptions()
+	hooks.SetRecordPath(recordOpts, path)
+
+	cluster, err := srv.NewCluster(recordOpts)
+	require.NoError(t, err)
+
+	run := func(cluster *cbcolumnar.Cluster) ([]int, *cbcolumnar.QueryMetadata, error, error) {
+		scope := cluster.Database("db").Scope("scope")
+
+		rows, meta, err := cbcolumnar.ExecuteQueryAs[int](context.Background(), scope, "SELECT RAW $1",
+			cbcolumnar.NewQueryOptions().SetPositionalParameters([]interface{}{1}))
+		require.NoError(t, err)
+
+		res, err := cluster.ExecuteQuery(context.Background(), "SELECT RAW 1")
+		require.NoError(t, err)
+
+		for row := res.NextRow(); row != nil; row = res.NextRow() { // nolint: revive
+		}
+
+		_, syntaxErr := cluster.ExecuteQuery(context.Background(), "SELEC 1")
+
+		return rows, meta, res.Err(), syntaxErr
+	}
+
+	recordedRows, recordedMeta, recordedStreamErr, recordedSyntaxErr := run(cluster)
+
+	err = cluster.Close()
+	require.NoError(t, err)
+
+	replayCluster, err := cbcolumnartest.NewReplayCluster(path)
+	require.NoError(t, err)
+	defer func() {
+		err := replayCluster.Close()
+		assert.NoError(t, err)
+	}()
+
+	rows, meta, streamErr, syntaxErr := run(replayCluster)
+
+	assert.Equal(t, recordedRows, rows)
+	assert.Equal(t, recordedMeta.RequestID, meta.RequestID)
+	assert.Equal(t, recordedMeta.Metrics, meta.Metrics)
+
+	require.ErrorIs(t, streamErr, cbcolumnar.ErrQuery)
+	assert.Equal(t, recordedStreamErr.Error(), streamErr.Error())
+
+	var queryErr *cbcolumnar.QueryError
+	require.ErrorAs(t, syntaxErr, &queryErr)
+	assert.Equal(t, 24000, queryErr.Code())
+	assert.Equal(t, recordedSyntaxErr.Error(), syntaxErr.Error())
+
+	// Queries with different parameters do not match.
+	_, err = replayCluster.ExecuteQuery(context.Background(), "SELECT RAW $1",
+		cbcolumnar.NewQueryOptions().SetPositionalParameters([]interface{}{2}))
+	require.ErrorIs(t, err, cbcolumnar.ErrColumnar)
+
+	// Each recorded query is only replayed once.
+	_, err = replayCluster.ExecuteQuery(context.Background(), "SELEC 1")
+	require.ErrorIs(t, err, cbcolumnar.ErrColumnar)
+	require.NotErrorIs(t, err, cbcolumnar.ErrQuery)
+}
@@ -0,0 +1,178 @@
+package cbcolumnartest
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Error is an error returned by the server in response to a query.
+type Error struct {
+	Code      uint32
+	Message   string
+	Retriable bool
+}
+
+// Warning is a warning returned by the server in response to a query.
+type Warning struct {
+	Code    uint32
+	Message string
+}
+
+// Response is the response the Server sends when a registered statement is executed.
+type Response struct {
+	// Rows are the rows to return, each row is marshalled to JSON.
+	Rows []any
+
+	// Errors are the errors to return.
+	Errors []Error
+
+	// Warnings are the warnings to return in the meta-data.
+	Warnings []Warning
+
+	// Signature is the signature to return in the meta-data.
+	// Default = {"*":"*"}
+	Signature json.RawMessage
+
+	// StatusCode is the HTTP status code to respond with. If both Rows and Errors are set then setting
+	// this to 200 will cause the errors to be sent after the rows, as happens when a query fails part way through.
+	// Default = 200 if no Errors are set, otherwise 400.
+	StatusCode int
+
+	// Delay is the amount of time the server waits before responding. If the delay exceeds the timeout
+	// sent with the query then the server responds with a timeout error instead, as the real server would.
+	Delay time.Duration
+}
+
+// NewResponse creates a new instance of Response.
+func NewResponse() *Response {
+	return &Response{
+		Rows:       nil,
+		Errors:     nil,
+		Warnings:   nil,
+		Signature:  nil,
+		StatusCode: 0,
+		Delay:      0,
+	}
+}
+
+// SetRows sets the Rows field in Response.
+func (r *Response) SetRows(rows []any) *Response {
+	r.Rows = rows
+
+	return r
+}
+
+// SetErrors sets the Errors field in Response.
+func (r *Response) SetErrors(errs []Error) *Response {
+	r.Errors = errs
+
+	return r
+}
+
+// SetWarnings sets the Warnings field in Response.
+func (r *Response) SetWarnings(warnings []Warning) *Response {
+	r.Warnings = warnings
+
+	return r
+}
+
+// SetSignature sets the Signature field in Response.
+func (r *Response) SetSignature(signature json.RawMessage) *Response {
+	r.Signature = signature
+
+	return r
+}
+
+// SetStatusCode sets the StatusCode field in Response.
+func (r *Response) SetStatusCode(statusCode int) *Response {
+	r.StatusCode = statusCode
+
+	return r
+}
+
+// SetDelay sets the Delay field in Response.
+func (r *Response) SetDelay(delay time.Duration) *Response {
+	r.Delay = delay
+
+	return r
+}
+
+func (r *Response) statusCode() int {
+	if r.StatusCode != 0 {
+		return r.StatusCode
+	}
+
+	if len(r.Errors) > 0 {
+		return 400
+	}
+
+	return 200
+}
+
+// Request is a query request which was received by the Server.
+type Request struct {
+	Statement            string
+	PositionalParameters []any
+	NamedParameters      map[string]any
+	ClientContextID      string
+	QueryContext         string
+	Priority             bool
+
+	// BasicAuth reports whether the request was sent with a username and password, which is not the case for
+	// requests which authenticate using a client certificate alone.
+	BasicAuth bool
+
+	// Payload contains the full request body as sent by the SDK.
+	Payload map[string]any
+}
+
+type jsonError struct {
+	Code      uint32 `json:"code"`
+	Msg       string `json:"msg"`
+	Retriable bool   `json:"retriable,omitempty"`
+}
+
+type jsonWarning struct {
+	Code uint32 `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+type jsonMetrics struct {
+	ElapsedTime      string `json:"elapsedTime"`
+	ExecutionTime    string `json:"executionTime"`
+	ResultCount      uint64 `json:"resultCount"`
+	ResultSize       uint64 `json:"resultSize"`
+	ProcessedObjects uint64 `json:"processedObjects"`
+	ErrorCount       uint64 `json:"errorCount,omitempty"`
+	WarningCount     uint64 `json:"warningCount,omitempty"`
+}
+
+type jsonResponse struct {
+	RequestID       string            `json:"requestID"`
+	ClientContextID string            `json:"clientContextID,omitempty"`
+	Signature       json.RawMessage   `json:"signature,omitempty"`
+	Results         []json.RawMessage `json:"results,omitempty"`
+	Errors          []jsonError       `json:"errors,omitempty"`
+	Warnings        []jsonWarning     `json:"warnings,omitempty"`
+	Status          string            `json:"status"`
+	Metrics         jsonMetrics       `json:"metrics"`
+}
+
+type jsonHandleResponse struct {
+	RequestID string       `json:"requestID"`
+	Status    string       `json:"status"`
+	Handle    string       `json:"handle,omitempty"`
+	Metrics   *jsonMetrics `json:"metrics,omitempty"`
+}
+
+type jsonClusterConfigNode struct {
+	Hostname string         `json:"hostname"`
+	ThisNode bool           `json:"thisNode"`
+	Services map[string]int `json:"services"`
+}
+
+type jsonClusterConfig struct {
+	Rev      int64                   `json:"rev"`
+	RevEpoch int64                   `json:"revEpoch"`
+	NodesExt []jsonClusterConfigNode `json:"nodesExt"`
+}
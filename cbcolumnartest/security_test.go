@@ -0,0 +1,274 @@
+package cbcolumnartest_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/couchbase/gocbcolumnar/cbcolumnartest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSelfSignedCertificatePEM(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "untrusted"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// newClusterWithSecurityOptions creates a cluster which talks to the server using the security options
+// provided, rather than trusting the server certificate. Any connection string options are appended to
+// the connection string.
+func newClusterWithSecurityOptions(t *testing.T, srv *cbcolumnartest.Server, connStrOptions string,
+	securityOpts *cbcolumnar.SecurityOptions) (*cbcolumnar.Cluster, error) {
+	t.Helper()
+
+	opts := cbcolumnar.NewClusterOptions().SetSecurityOptions(securityOpts)
+
+	cluster, err := cbcolumnar.NewCluster(srv.ConnectionString()+connStrOptions,
+		cbcolumnar.NewCredential(cbcolumnartest.Username, cbcolumnartest.Password), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	t.Cleanup(func() {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	})
+
+	return cluster, nil
+}
+
+func TestServerTrustOnlyPemFileReload(t *testing.T) {
+	srv := cbcolumnartest.NewServer()
+	defer srv.Close()
+
+	srv.RegisterResponse("SELECT RAW 1", cbcolumnartest.NewResponse().SetRows([]any{1}))
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(path, newSelfSignedCertificatePEM(t), 0o600))
+
+	cluster, err := newClusterWithSecurityOptions(t, srv, "", cbcolumnar.NewSecurityOptions().
+		SetTrustOnly(cbcolumnar.TrustOnlyPemFile{
+			Path:           path,
+			ReloadInterval: 10 * time.Millisecond,
+		}))
+	require.NoError(t, err)
+
+	_, err = cluster.ExecuteQuery(context.Background(), "SELECT RAW 1")
+	require.Error(t, err)
+
+	serverPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(path, serverPEM, 0o600))
+
+	require.Eventually(t, func() bool {
+		_, err := cbcolumnar.ExecuteQueryOne[int](context.Background(), cluster, "SELECT RAW 1")
+
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+
+	// A file which fails to parse is rejected, and the existing certificates continue to be used.
+	require.NoError(t, os.WriteFile(path, []byte("not a certificate"), 0o600))
+	time.Sleep(50 * time.Millisecond)
+
+	_, err = cbcolumnar.ExecuteQueryOne[int](context.Background(), cluster, "SELECT RAW 1")
+	require.NoError(t, err)
+}
+
+func TestServerTrustOnlyPemFileReloadInvalid(t *testing.T) {
+	srv := cbcolumnartest.NewServer()
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(path, []byte("not a certificate"), 0o600))
+
+	_, err := newClusterWithSecurityOptions(t, srv, "", cbcolumnar.NewSecurityOptions().
+		SetTrustOnly(cbcolumnar.TrustOnlyPemFile{
+			Path:           path,
+			ReloadInterval: time.Second,
+		}))
+	require.Error(t, err)
+}
+
+func TestServerTrustOnlyPemStringInvalid(t *testing.T) {
+	srv := cbcolumnartest.NewServer()
+	defer srv.Close()
+
+	serverPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	_, err := newClusterWithSecurityOptions(t, srv, "", cbcolumnar.NewSecurityOptions().
+		SetTrustOnly(cbcolumnar.TrustOnlyPemString{Pem: "not a certificate"}))
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+	assert.Contains(t, err.Error(), "TrustOnlyPemString, 0 certificates were loaded")
+
+	corrupt := append(append([]byte{}, serverPEM...),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("corrupt")})...)
+
+	_, err = newClusterWithSecurityOptions(t, srv, "", cbcolumnar.NewSecurityOptions().
+		SetTrustOnly(cbcolumnar.TrustOnlyPemString{Pem: string(corrupt)}))
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+	assert.Contains(t, err.Error(), "TrustOnlyPemString, 1 certificates were loaded")
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+	_, err = newClusterWithSecurityOptions(t, srv, "", cbcolumnar.NewSecurityOptions().
+		SetTrustOnly(cbcolumnar.TrustOnlyPemFile{Path: path, ReloadInterval: 0}))
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+	assert.Contains(t, err.Error(), path)
+}
+
+func TestServerTrustOnlyCombined(t *testing.T) {
+	srv := cbcolumnartest.NewServer()
+	defer srv.Close()
+
+	srv.RegisterResponse("SELECT RAW 1", cbcolumnartest.NewResponse().SetRows([]any{1}))
+
+	serverPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	cluster, err := newClusterWithSecurityOptions(t, srv, "", cbcolumnar.NewSecurityOptions().
+		AddTrustOnly(cbcolumnar.TrustOnlyPemString{Pem: string(newSelfSignedCertificatePEM(t))}).
+		AddTrustOnly(cbcolumnar.TrustOnlyPemString{Pem: string(serverPEM)}))
+	require.NoError(t, err)
+
+	row, err := cbcolumnar.ExecuteQueryOne[int](context.Background(), cluster, "SELECT RAW 1")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, row)
+
+	_, err = newClusterWithSecurityOptions(t, srv, "", cbcolumnar.NewSecurityOptions().
+		AddTrustOnly(cbcolumnar.TrustOnlyCertificates{Certificates: x509.NewCertPool()}))
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+// newClientCertificate creates a certificate authority and a client certificate signed by it.
+func newClientCertificate(t *testing.T) (*x509.CertPool, tls.Certificate) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "client-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "service-account"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+
+	return pool, tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}
+}
+
+func TestServerCertificateCredential(t *testing.T) {
+	srv := cbcolumnartest.NewServer()
+	defer srv.Close()
+
+	pool, cert := newClientCertificate(t)
+	srv.SetClientCAs(pool)
+
+	srv.RegisterResponse("SELECT RAW 1", cbcolumnartest.NewResponse().SetRows([]any{1}))
+
+	cluster, err := srv.NewClusterWithCredentialProvider(
+		cbcolumnar.NewStaticCredentialProvider(cbcolumnar.NewTLSCertificateCredential(cert)))
+	require.NoError(t, err)
+
+	defer func() {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}()
+
+	row, err := cbcolumnar.ExecuteQueryOne[int](context.Background(), cluster, "SELECT RAW 1")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, row)
+
+	// The query is authenticated by the client certificate alone, without an empty username and password.
+	requests := srv.Requests()
+	require.Len(t, requests, 1)
+	assert.False(t, requests[0].BasicAuth)
+
+	report, err := cluster.Ping(context.Background())
+	require.NoError(t, err)
+
+	assert.True(t, report.Ok())
+}
+
+func TestServerCertificateCredentialUntrusted(t *testing.T) {
+	srv := cbcolumnartest.NewServer()
+	defer srv.Close()
+
+	_, cert := newClientCertificate(t)
+
+	cluster, err := srv.NewClusterWithCredentialProvider(
+		cbcolumnar.NewStaticCredentialProvider(cbcolumnar.NewTLSCertificateCredential(cert)))
+	require.NoError(t, err)
+
+	defer func() {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	_, err = cluster.ExecuteQuery(ctx, "SELECT RAW 1")
+	require.Error(t, err)
+
+	assert.Empty(t, srv.Requests())
+}
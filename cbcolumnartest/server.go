@@ -0,0 +1,661 @@
+// Package cbcolumnartest provides an in-process stand-in for the Columnar analytics service, allowing
+// code which uses cbcolumnar to be tested without a running cluster or network access.
+//
+// A Server is started with NewServer, the responses for the statements which are expected to be executed
+// are registered with RegisterResponse, and a *cbcolumnar.Cluster which talks to the server is created
+// with NewCluster:
+//
+//	srv := cbcolumnartest.NewServer()
+//	defer srv.Close()
+//
+//	srv.RegisterResponse("SELECT RAW 1", cbcolumnartest.NewResponse().SetRows([]any{1}))
+//
+//	cluster, err := srv.NewCluster()
+//	if err != nil {
+//		panic(err)
+//	}
+//	defer cluster.Close()
+//
+//	res, err := cluster.ExecuteQuery(context.Background(), "SELECT RAW 1")
+//
+// The server supports executing queries against both the Cluster and a Scope, StartQuery and query handles,
+// Cluster.CancelQuery, and Cluster.Ping and Cluster.WaitUntilReady. Requests authenticate with a username and
+// password, or with a client certificate once SetClientCAs has been called.
+//
+// Alongside the analytics HTTP API the server implements the small part of the key-value protocol which is
+// needed to bootstrap against a node and fetch the cluster config, so the clusters created by NewCluster connect
+// to it in the same way as to a real cluster. The cluster config describes a single node running the server.
+// Behavior which depends on DNS, such as SRV record refresh, is not covered.
+package cbcolumnartest
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+)
+
+const (
+	// Username is the username that the Server accepts by default.
+	Username = "Administrator"
+
+	// Password is the password that the Server accepts by default.
+	Password = "password"
+)
+
+// ErrorCodeNoResponse is the error code the Server returns when a statement is executed which has
+// no registered response.
+const ErrorCodeNoResponse = 24000
+
+type activeQuery struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+type asyncQuery struct {
+	requestID string
+	handle    string
+	response  *Response
+	readyAt   time.Time
+}
+
+// Server is a local HTTPS server which speaks the analytics query protocol, along with a key-value listener
+// which can be bootstrapped against.
+type Server struct {
+	srv *httptest.Server
+	kv  *kvServer
+
+	lock      sync.Mutex
+	username  string
+	password  string
+	clientCAs *x509.CertPool
+	responses map[string]*Response
+	requests  []Request
+	active    map[string]*activeQuery
+	async     map[string]*asyncQuery
+	nextID    uint64
+}
+
+// NewServer creates and starts a new Server. The Server must be closed once it is no longer needed.
+func NewServer() *Server {
+	s := &Server{
+		srv:       nil,
+		kv:        nil,
+		lock:      sync.Mutex{},
+		username:  Username,
+		password:  Password,
+		clientCAs: nil,
+		responses: make(map[string]*Response),
+		requests:  nil,
+		active:    make(map[string]*activeQuery),
+		async:     make(map[string]*asyncQuery),
+		nextID:    0,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/request", s.handleQuery)
+	mux.HandleFunc("GET /api/v1/request/status/{id}/{handle}", s.handleStatus)
+	mux.HandleFunc("GET /api/v1/request/result/{id}/{handle}", s.handleResult)
+	mux.HandleFunc("DELETE /api/v1/request/result/{id}/{handle}", s.handleDiscard)
+	mux.HandleFunc("DELETE /api/v1/active_requests", s.handleCancel)
+	mux.HandleFunc("GET /admin/ping", s.handlePing)
+
+	// Client certificates are requested but verified by the Server, so that they are only accepted once
+	// SetClientCAs has been called.
+	s.srv = httptest.NewUnstartedServer(s.authenticate(mux))
+	s.srv.TLS = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: tls.RequestClientCert,
+	}
+	s.srv.StartTLS()
+
+	kv, err := newKVServer(&tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: s.srv.TLS.Certificates,
+		ClientAuth:   tls.RequestClientCert,
+	}, s.checkCredential, func(state tls.ConnectionState) bool {
+		return s.checkCertificate(state.PeerCertificates)
+	}, s.clusterConfig)
+	if err != nil {
+		s.srv.Close()
+
+		panic(fmt.Sprintf("cbcolumnartest: failed to listen for key-value connections: %v", err))
+	}
+
+	s.kv = kv
+
+	return s
+}
+
+// ConnectionString returns the connection string for bootstrapping against the Server, of the form
+// couchbases://ipaddr:port.
+func (s *Server) ConnectionString() string {
+	return fmt.Sprintf("couchbases://127.0.0.1:%d", s.kv.Port())
+}
+
+// URL returns the base URL of the analytics service of the Server, of the form https://ipaddr:port.
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// Certificate returns the certificate used by the Server.
+func (s *Server) Certificate() *x509.Certificate {
+	return s.srv.Certificate()
+}
+
+// Close shuts down the Server, blocking until all outstanding requests have completed.
+func (s *Server) Close() {
+	s.lock.Lock()
+	for _, query := range s.active {
+		query.cancel()
+	}
+	s.lock.Unlock()
+
+	s.kv.Close()
+	s.srv.Close()
+}
+
+// NewCluster creates a *cbcolumnar.Cluster which talks to the Server, trusting its certificate and
+// authenticating with Username and Password.
+// Any options provided are applied to the Cluster, except for those controlling which certificates are trusted.
+func (s *Server) NewCluster(opts ...*cbcolumnar.ClusterOptions) (*cbcolumnar.Cluster, error) {
+	return s.NewClusterWithCredentialProvider(
+		cbcolumnar.NewStaticCredentialProvider(cbcolumnar.NewCredential(Username, Password)), opts...)
+}
+
+// NewClusterWithCredentialProvider creates a *cbcolumnar.Cluster which talks to the Server, trusting its
+// certificate and authenticating with the credential fetched from the provider.
+// Any options provided are applied to the Cluster, except for those controlling which certificates are trusted.
+func (s *Server) NewClusterWithCredentialProvider(provider cbcolumnar.CredentialProvider,
+	opts ...*cbcolumnar.ClusterOptions) (*cbcolumnar.Cluster, error) {
+	pool := x509.NewCertPool()
+	pool.AddCert(s.srv.Certificate())
+
+	serverOpts := cbcolumnar.NewClusterOptions().
+		SetSecurityOptions(cbcolumnar.NewSecurityOptions().SetTrustOnly(cbcolumnar.TrustOnlyCertificates{
+			Certificates: pool,
+		}))
+
+	// The server options are applied last so that they always take precedence.
+	clusterOpts := make([]*cbcolumnar.ClusterOptions, 0, len(opts)+1)
+	clusterOpts = append(clusterOpts, opts...)
+	clusterOpts = append(clusterOpts, serverOpts)
+
+	cluster, err := cbcolumnar.NewClusterWithCredentialProvider(s.ConnectionString(), provider, clusterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cluster: %w", err)
+	}
+
+	return cluster, nil
+}
+
+// SetCredential changes the username and password that the Server accepts, which can be used to simulate
+// the rotation of credentials. Requests which have already been authenticated are unaffected.
+func (s *Server) SetCredential(username, password string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.username = username
+	s.password = password
+}
+
+// SetClientCAs makes the Server accept requests which present a client certificate signed by one of the
+// certificate authorities in pool, as well as those which authenticate with the username and password. This can
+// be used to test authenticating using mutual TLS.
+func (s *Server) SetClientCAs(pool *x509.CertPool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.clientCAs = pool
+}
+
+// RegisterResponse registers the response to send when the statement is executed. Leading and trailing
+// whitespace is ignored when matching statements. Registering a response for a statement which already
+// has a response replaces it.
+func (s *Server) RegisterResponse(statement string, resp *Response) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.responses[strings.TrimSpace(statement)] = resp
+}
+
+// Requests returns all query requests which have been received by the Server, in the order they were received.
+func (s *Server) Requests() []Request {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	requests := make([]Request, len(s.requests))
+	copy(requests, s.requests)
+
+	return requests
+}
+
+// checkCredential reports whether the username and password are those that the Server accepts.
+func (s *Server) checkCredential(username, password string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return username == s.username && password == s.password
+}
+
+// checkCertificate reports whether the client certificate chain was signed by one of the certificate authorities
+// set by SetClientCAs.
+func (s *Server) checkCertificate(chain []*x509.Certificate) bool {
+	s.lock.Lock()
+	pool := s.clientCAs
+	s.lock.Unlock()
+
+	if pool == nil || len(chain) == 0 {
+		return false
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         pool,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	return err == nil
+}
+
+// clusterConfig returns the cluster config sent to clients which bootstrap against the Server, describing a
+// single node which runs the key-value and analytics services. gocbcore only accepts a config which also has a
+// management endpoint, which the HTTPS server stands in for as it is never used for analytics.
+func (s *Server) clusterConfig() []byte {
+	serverURL, err := url.Parse(s.srv.URL)
+	if err != nil {
+		return nil
+	}
+
+	analyticsPort, err := strconv.Atoi(serverURL.Port())
+	if err != nil {
+		return nil
+	}
+
+	config, err := json.Marshal(jsonClusterConfig{
+		Rev:      1,
+		RevEpoch: 1,
+		NodesExt: []jsonClusterConfigNode{{
+			Hostname: "127.0.0.1",
+			ThisNode: true,
+			Services: map[string]int{
+				"kvSSL":   s.kv.Port(),
+				"mgmtSSL": analyticsPort,
+				"cbasSSL": analyticsPort,
+			},
+		}},
+	})
+	if err != nil {
+		return nil
+	}
+
+	return config
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+
+		switch {
+		case ok && s.checkCredential(username, password):
+		case r.TLS != nil && s.checkCertificate(r.TLS.PeerCertificates):
+		default:
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handlePing(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	var payload map[string]any
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %s", err), http.StatusBadRequest)
+
+		return
+	}
+
+	req := newRequest(payload, r.Header)
+
+	s.lock.Lock()
+	s.nextID++
+	requestID := fmt.Sprintf("%08d-0000-0000-0000-000000000000", s.nextID)
+	s.requests = append(s.requests, req)
+	resp, ok := s.responses[strings.TrimSpace(req.Statement)]
+	s.lock.Unlock()
+
+	if !ok {
+		resp = NewResponse().SetErrors([]Error{{
+			Code:      ErrorCodeNoResponse,
+			Message:   fmt.Sprintf("cbcolumnartest: no response registered for statement: %s", req.Statement),
+			Retriable: false,
+		}})
+	}
+
+	if mode, _ := payload["mode"].(string); mode == "async" {
+		s.startAsyncQuery(w, requestID, resp)
+
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	query := &activeQuery{
+		cancel:    cancel,
+		cancelled: false,
+	}
+
+	if req.ClientContextID != "" {
+		s.lock.Lock()
+		s.active[req.ClientContextID] = query
+		s.lock.Unlock()
+
+		defer func() {
+			s.lock.Lock()
+			delete(s.active, req.ClientContextID)
+			s.lock.Unlock()
+		}()
+	}
+
+	start := time.Now()
+
+	timeout, _ := time.ParseDuration(fmt.Sprint(payload["timeout"]))
+	if timeout > 0 && resp.Delay > timeout {
+		if !sleep(ctx, timeout) && !s.isCancelled(query) {
+			return
+		}
+
+		resp = NewResponse().SetStatusCode(500).SetErrors([]Error{{
+			Code:      21002,
+			Message:   "Request timed out and will be cancelled",
+			Retriable: false,
+		}})
+	} else if !sleep(ctx, resp.Delay) && !s.isCancelled(query) {
+		return
+	}
+
+	if s.isCancelled(query) {
+		resp = NewResponse().SetStatusCode(500).SetErrors([]Error{{
+			Code:      21004,
+			Message:   "Request cancelled",
+			Retriable: false,
+		}})
+	}
+
+	writeJSON(w, resp.statusCode(), newJSONResponse(requestID, req.ClientContextID, resp, time.Since(start)))
+}
+
+func (s *Server) isCancelled(query *activeQuery) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return query.cancelled
+}
+
+func (s *Server) startAsyncQuery(w http.ResponseWriter, requestID string, resp *Response) {
+	if len(resp.Errors) > 0 {
+		writeJSON(w, resp.statusCode(), newJSONResponse(requestID, "", resp, 0))
+
+		return
+	}
+
+	query := &asyncQuery{
+		requestID: requestID,
+		handle:    "handle-" + requestID[:8],
+		response:  resp,
+		readyAt:   time.Now().Add(resp.Delay),
+	}
+
+	s.lock.Lock()
+	s.async[requestID] = query
+	s.lock.Unlock()
+
+	writeJSON(w, http.StatusAccepted, jsonHandleResponse{
+		RequestID: requestID,
+		Status:    string(cbcolumnar.QueryStatusQueued),
+		Handle:    fmt.Sprintf("/api/v1/request/status/%s/%s", requestID, query.handle),
+		Metrics:   nil,
+	})
+}
+
+func (s *Server) lookupAsyncQuery(w http.ResponseWriter, r *http.Request) (*asyncQuery, bool) {
+	s.lock.Lock()
+	query, ok := s.async[r.PathValue("id")]
+	s.lock.Unlock()
+
+	if !ok || query.handle != r.PathValue("handle") {
+		http.Error(w, "Not Found", http.StatusNotFound)
+
+		return nil, false
+	}
+
+	return query, true
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	query, ok := s.lookupAsyncQuery(w, r)
+	if !ok {
+		return
+	}
+
+	if time.Now().Before(query.readyAt) {
+		writeJSON(w, http.StatusOK, jsonHandleResponse{
+			RequestID: query.requestID,
+			Status:    string(cbcolumnar.QueryStatusRunning),
+			Handle:    "",
+			Metrics:   nil,
+		})
+
+		return
+	}
+
+	metrics := newJSONMetrics(query.response, query.response.Delay)
+
+	writeJSON(w, http.StatusOK, jsonHandleResponse{
+		RequestID: query.requestID,
+		Status:    string(cbcolumnar.QueryStatusSuccess),
+		Handle:    fmt.Sprintf("/api/v1/request/result/%s/%s", query.requestID, query.handle),
+		Metrics:   &metrics,
+	})
+}
+
+func (s *Server) handleResult(w http.ResponseWriter, r *http.Request) {
+	query, ok := s.lookupAsyncQuery(w, r)
+	if !ok {
+		return
+	}
+
+	rows := marshalRows(query.response.Rows)
+	if rows == nil {
+		rows = []json.RawMessage{}
+	}
+
+	writeJSON(w, http.StatusOK, rows)
+}
+
+func (s *Server) handleDiscard(w http.ResponseWriter, r *http.Request) {
+	query, ok := s.lookupAsyncQuery(w, r)
+	if !ok {
+		return
+	}
+
+	s.lock.Lock()
+	delete(s.async, query.requestID)
+	s.lock.Unlock()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	clientContextID := r.URL.Query().Get("client_context_id")
+
+	s.lock.Lock()
+	query, ok := s.active[clientContextID]
+	if ok {
+		query.cancelled = true
+		query.cancel()
+	}
+	s.lock.Unlock()
+
+	if !ok {
+		http.Error(w, "Not Found", http.StatusNotFound)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func newRequest(payload map[string]any, header http.Header) Request {
+	req := Request{
+		Statement:            "",
+		PositionalParameters: nil,
+		NamedParameters:      nil,
+		ClientContextID:      "",
+		QueryContext:         "",
+		Priority:             header.Get("Analytics-Priority") == "-1",
+		BasicAuth:            header.Get("Authorization") != "",
+		Payload:              payload,
+	}
+
+	req.Statement, _ = payload["statement"].(string)
+	req.ClientContextID, _ = payload["client_context_id"].(string)
+	req.QueryContext, _ = payload["query_context"].(string)
+	req.PositionalParameters, _ = payload["args"].([]any)
+
+	for key, value := range payload {
+		if !strings.HasPrefix(key, "$") {
+			continue
+		}
+
+		if req.NamedParameters == nil {
+			req.NamedParameters = make(map[string]any)
+		}
+
+		req.NamedParameters[strings.TrimPrefix(key, "$")] = value
+	}
+
+	return req
+}
+
+func newJSONResponse(requestID, clientContextID string, resp *Response, elapsed time.Duration) jsonResponse {
+	signature := resp.Signature
+	if signature == nil {
+		signature = json.RawMessage(`{"*":"*"}`)
+	}
+
+	status := string(cbcolumnar.QueryStatusSuccess)
+	if len(resp.Errors) > 0 {
+		status = string(cbcolumnar.QueryStatusFatal)
+	}
+
+	errs := make([]jsonError, len(resp.Errors))
+	for i, err := range resp.Errors {
+		errs[i] = jsonError{
+			Code:      err.Code,
+			Msg:       err.Message,
+			Retriable: err.Retriable,
+		}
+	}
+
+	warnings := make([]jsonWarning, len(resp.Warnings))
+	for i, warning := range resp.Warnings {
+		warnings[i] = jsonWarning{
+			Code: warning.Code,
+			Msg:  warning.Message,
+		}
+	}
+
+	return jsonResponse{
+		RequestID:       requestID,
+		ClientContextID: clientContextID,
+		Signature:       signature,
+		Results:         marshalRows(resp.Rows),
+		Errors:          errs,
+		Warnings:        warnings,
+		Status:          status,
+		Metrics:         newJSONMetrics(resp, elapsed),
+	}
+}
+
+func newJSONMetrics(resp *Response, elapsed time.Duration) jsonMetrics {
+	var size uint64
+	for _, row := range marshalRows(resp.Rows) {
+		size += uint64(len(row))
+	}
+
+	return jsonMetrics{
+		ElapsedTime:      elapsed.String(),
+		ExecutionTime:    elapsed.String(),
+		ResultCount:      uint64(len(resp.Rows)),
+		ResultSize:       size,
+		ProcessedObjects: uint64(len(resp.Rows)),
+		ErrorCount:       uint64(len(resp.Errors)),
+		WarningCount:     uint64(len(resp.Warnings)),
+	}
+}
+
+func marshalRows(rows []any) []json.RawMessage {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	marshalled := make([]json.RawMessage, len(rows))
+
+	for i, row := range rows {
+		data, err := json.Marshal(row)
+		if err != nil {
+			data, _ = json.Marshal(fmt.Sprintf("cbcolumnartest: failed to marshal row: %s", err))
+		}
+
+		marshalled[i] = data
+	}
+
+	return marshalled
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	// Errors writing the response mean the client has gone away, so there is nothing more we can do.
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// sleep waits for the duration to elapse, returning false if the context is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
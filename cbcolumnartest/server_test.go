@@ -0,0 +1,264 @@
+package cbcolumnartest_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/couchbase/gocbcolumnar/cbcolumnartest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCluster(t *testing.T, opts ...*cbcolumnar.ClusterOptions) (*cbcolumnartest.Server, *cbcolumnar.Cluster) {
+	t.Helper()
+
+	srv := cbcolumnartest.NewServer()
+	t.Cleanup(srv.Close)
+
+	cluster, err := srv.NewCluster(opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	})
+
+	return srv, cluster
+}
+
+func TestServerQuery(t *testing.T) {
+	srv, cluster := newTestCluster(t)
+
+	srv.RegisterResponse("SELECT * FROM airline", cbcolumnartest.NewResponse().
+		SetRows([]any{
+			map[string]any{"name": "foo", "id": 1},
+			map[string]any{"name": "bar", "id": 2},
+		}).
+		SetSignature(json.RawMessage(`{"name":"string","id":"int64"}`)).
+		SetWarnings([]cbcolumnartest.Warning{{Code: 1, Message: "a warning"}}))
+
+	type airline struct {
+		Name string `json:"name"`
+		ID   int    `json:"id"`
+	}
+
+	rows, meta, err := cbcolumnar.ExecuteQueryAs[airline](context.Background(), cluster, "SELECT * FROM airline")
+	require.NoError(t, err)
+
+	assert.Equal(t, []airline{{Name: "foo", ID: 1}, {Name: "bar", ID: 2}}, rows)
+
+	assert.NotEmpty(t, meta.RequestID)
+	assert.NotEmpty(t, meta.ClientContextID)
+	assert.Equal(t, cbcolumnar.QueryStatusSuccess, meta.Status)
+	assert.Equal(t, uint64(2), meta.Metrics.ResultCount)
+	assert.Equal(t, []cbcolumnar.QueryWarning{{Code: 1, Message: "a warning"}}, meta.Warnings)
+	require.NotNil(t, meta.Signature)
+	assert.Equal(t, map[string]string{"name": "string", "id": "int64"}, meta.Signature.Columns)
+}
+
+func TestServerQueryScope(t *testing.T) {
+	srv, cluster := newTestCluster(t)
+
+	srv.RegisterResponse("SELECT RAW $foo", cbcolumnartest.NewResponse().SetRows([]any{"bar"}))
+
+	scope := cluster.Database("db").Scope("scope")
+
+	row, err := cbcolumnar.ExecuteQueryOne[string](context.Background(), scope, "SELECT RAW $foo",
+		cbcolumnar.NewQueryOptions().
+			SetNamedParameters(map[string]interface{}{"foo": "bar"}).
+			SetClientContextID("my-context-id").
+			SetPriority(true))
+	require.NoError(t, err)
+
+	assert.Equal(t, "bar", row)
+
+	requests := srv.Requests()
+	require.Len(t, requests, 1)
+
+	assert.Equal(t, "SELECT RAW $foo", requests[0].Statement)
+	assert.Equal(t, map[string]any{"foo": "bar"}, requests[0].NamedParameters)
+	assert.Equal(t, "my-context-id", requests[0].ClientContextID)
+	assert.Equal(t, "default:`db`.`scope`", requests[0].QueryContext)
+	assert.True(t, requests[0].Priority)
+}
+
+func TestServerQueryError(t *testing.T) {
+	srv, cluster := newTestCluster(t)
+
+	srv.RegisterResponse("SELEC 1", cbcolumnartest.NewResponse().SetErrors([]cbcolumnartest.Error{{
+		Code:      24000,
+		Message:   "Syntax error",
+		Retriable: false,
+	}}))
+
+	_, err := cluster.ExecuteQuery(context.Background(), "SELEC 1")
+	require.ErrorIs(t, err, cbcolumnar.ErrQuery)
+
+	var queryErr *cbcolumnar.QueryError
+	require.ErrorAs(t, err, &queryErr)
+
+	assert.Equal(t, 24000, queryErr.Code())
+	assert.Equal(t, "Syntax error", queryErr.Message())
+
+	_, err = cluster.ExecuteQuery(context.Background(), "SELECT 1")
+	require.ErrorAs(t, err, &queryErr)
+
+	assert.Equal(t, cbcolumnartest.ErrorCodeNoResponse, queryErr.Code())
+}
+
+func TestServerQueryErrorAfterRows(t *testing.T) {
+	srv, cluster := newTestCluster(t)
+
+	srv.RegisterResponse("SELECT RAW 1", cbcolumnartest.NewResponse().
+		SetRows([]any{1, 2}).
+		SetErrors([]cbcolumnartest.Error{{Code: 23000, Message: "Internal error", Retriable: false}}).
+		SetStatusCode(200))
+
+	res, err := cluster.ExecuteQuery(context.Background(), "SELECT RAW 1")
+	require.NoError(t, err)
+
+	var count int
+	for row := res.NextRow(); row != nil; row = res.NextRow() {
+		count++
+	}
+
+	assert.Equal(t, 2, count)
+	require.ErrorIs(t, res.Err(), cbcolumnar.ErrQuery)
+}
+
+func TestServerQueryTimeout(t *testing.T) {
+	srv, cluster := newTestCluster(t, cbcolumnar.NewClusterOptions().
+		SetTimeoutOptions(cbcolumnar.NewTimeoutOptions().SetQueryTimeout(100*time.Millisecond)))
+
+	srv.RegisterResponse("SELECT RAW 1", cbcolumnartest.NewResponse().SetRows([]any{1}).SetDelay(1*time.Second))
+
+	_, err := cluster.ExecuteQuery(context.Background(), "SELECT RAW 1")
+	require.ErrorIs(t, err, cbcolumnar.ErrTimeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	srv.RegisterResponse("SELECT RAW 2", cbcolumnartest.NewResponse().SetRows([]any{2}).SetDelay(10*time.Second))
+
+	_, err = cluster.ExecuteQuery(ctx, "SELECT RAW 2")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestServerCancelQuery(t *testing.T) {
+	srv, cluster := newTestCluster(t)
+
+	srv.RegisterResponse("SELECT RAW 1", cbcolumnartest.NewResponse().SetRows([]any{1}).SetDelay(10*time.Second))
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := cluster.ExecuteQuery(context.Background(), "SELECT RAW 1",
+			cbcolumnar.NewQueryOptions().SetClientContextID("cancel-me"))
+		errCh <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		return cluster.CancelQuery(context.Background(), "cancel-me") == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	err := <-errCh
+	require.ErrorIs(t, err, cbcolumnar.ErrQuery)
+
+	err = cluster.CancelQuery(context.Background(), "cancel-me")
+	require.ErrorIs(t, err, cbcolumnar.ErrColumnar)
+}
+
+func TestServerStartQuery(t *testing.T) {
+	srv, cluster := newTestCluster(t)
+
+	srv.RegisterResponse("SELECT RAW 1", cbcolumnartest.NewResponse().SetRows([]any{1, 2, 3}).SetDelay(200*time.Millisecond))
+
+	handle, err := cluster.StartQuery(context.Background(), "SELECT RAW 1")
+	require.NoError(t, err)
+
+	status, err := handle.Status(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, cbcolumnar.QueryStatusRunning, status.Status)
+
+	res, err := handle.FetchResults(context.Background())
+	require.NoError(t, err)
+
+	rows, _, err := cbcolumnar.BufferQueryResult[int](res)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{1, 2, 3}, rows)
+
+	err = handle.Discard(context.Background())
+	require.NoError(t, err)
+
+	_, err = handle.Status(context.Background())
+	require.Error(t, err)
+}
+
+func TestServerLoadQueryHandleRejectsUnknownEndpoint(t *testing.T) {
+	srv, cluster := newTestCluster(t)
+
+	srv.RegisterResponse("SELECT RAW 1", cbcolumnartest.NewResponse().SetRows([]any{1}))
+
+	handle, err := cluster.StartQuery(context.Background(), "SELECT RAW 1")
+	require.NoError(t, err)
+
+	data, err := json.Marshal(handle)
+	require.NoError(t, err)
+
+	var fields map[string]any
+
+	err = json.Unmarshal(data, &fields)
+	require.NoError(t, err)
+
+	var requests atomic.Int32
+
+	other := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(other.Close)
+
+	fields["endpoint"] = other.URL
+
+	data, err = json.Marshal(fields)
+	require.NoError(t, err)
+
+	loaded, err := cluster.LoadQueryHandle(data)
+	require.NoError(t, err)
+
+	_, err = loaded.Status(context.Background())
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+
+	_, err = loaded.FetchResults(context.Background())
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+
+	err = loaded.Discard(context.Background())
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+
+	assert.Zero(t, requests.Load())
+}
+
+func TestServerLoadQueryHandleRejectsInvalidPaths(t *testing.T) {
+	srv, cluster := newTestCluster(t)
+
+	for _, handle := range []map[string]string{
+		{"endpoint": srv.URL(), "statusHandle": "/admin/ping"},
+		{"endpoint": srv.URL(), "statusHandle": "/api/v1/request/status/../../../admin"},
+		{"endpoint": srv.URL(), "statusHandle": "/api/v1/request/status/1/2?x=y"},
+		{"endpoint": srv.URL(), "statusHandle": "/api/v1/request/status/1/2", "resultHandle": "/api/v1/request/status/1/2"},
+		{"endpoint": srv.URL() + "/path", "statusHandle": "/api/v1/request/status/1/2"},
+		{"endpoint": "http://" + srv.URL()[len("https://"):], "statusHandle": "/api/v1/request/status/1/2"},
+	} {
+		data, err := json.Marshal(handle)
+		require.NoError(t, err)
+
+		_, err = cluster.LoadQueryHandle(data)
+		assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument, handle)
+	}
+}
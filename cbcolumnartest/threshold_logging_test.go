@@ -0,0 +1,92 @@
+package cbcolumnartest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/couchbase/gocbcolumnar/cbcolumnartest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerThresholdLogging(t *testing.T) {
+	logger := &capturingLogger{}
+
+	srv, cluster := newTestCluster(t, cbcolumnar.NewClusterOptions().
+		SetLogger(logger).
+		SetLogRedactionLevel(cbcolumnar.RedactPartial).
+		SetThresholdLoggingOptions(cbcolumnar.NewThresholdLoggingOptions().
+			SetEnabled(true).
+			SetThreshold(0).
+			SetEmitInterval(20*time.Millisecond)))
+
+	srv.RegisterResponse("SELECT 1", cbcolumnartest.NewResponse().SetRows([]any{1}))
+
+	_, _, err := cbcolumnar.ExecuteQueryAs[int](context.Background(), cluster, "SELECT 1",
+		cbcolumnar.NewQueryOptions().SetClientContextID("slow-query"))
+	require.NoError(t, err)
+
+	var msg string
+
+	require.Eventually(t, func() bool {
+		msg = logger.Find("Threshold Log: ")
+
+		return msg != ""
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Contains(t, msg, `"total_count":1`)
+	assert.Contains(t, msg, `"statement":"<ud>SELECT 1</ud>"`)
+	assert.Contains(t, msg, `"client_context_id":"slow-query"`)
+	assert.Contains(t, msg, `"endpoint":"`+srv.URL())
+}
+
+func TestServerThresholdLoggingRedactFull(t *testing.T) {
+	logger := &capturingLogger{}
+
+	srv, cluster := newTestCluster(t, cbcolumnar.NewClusterOptions().
+		SetLogger(logger).
+		SetLogRedactionLevel(cbcolumnar.RedactFull).
+		SetThresholdLoggingOptions(cbcolumnar.NewThresholdLoggingOptions().
+			SetEnabled(true).
+			SetThreshold(0).
+			SetEmitInterval(20*time.Millisecond)))
+
+	srv.RegisterResponse("SELECT 1", cbcolumnartest.NewResponse().SetRows([]any{1}))
+
+	_, _, err := cbcolumnar.ExecuteQueryAs[int](context.Background(), cluster, "SELECT 1", nil)
+	require.NoError(t, err)
+
+	var msg string
+
+	require.Eventually(t, func() bool {
+		msg = logger.Find("Threshold Log: ")
+
+		return msg != ""
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Contains(t, msg, `"total_count":1`)
+	assert.NotContains(t, msg, `"statement"`)
+	assert.NotContains(t, msg, "SELECT 1")
+}
+
+func TestServerThresholdLoggingDisabledByDefault(t *testing.T) {
+	logger := &capturingLogger{}
+
+	srv, cluster := newTestCluster(t, cbcolumnar.NewClusterOptions().
+		SetLogger(logger).
+		SetThresholdLoggingOptions(cbcolumnar.NewThresholdLoggingOptions().
+			SetThreshold(0).
+			SetEmitInterval(20*time.Millisecond)))
+
+	srv.RegisterResponse("SELECT 1", cbcolumnartest.NewResponse().SetRows([]any{1}))
+
+	_, _, err := cbcolumnar.ExecuteQueryAs[int](context.Background(), cluster, "SELECT 1", nil)
+	require.NoError(t, err)
+
+	// Several emit intervals pass without a summary being logged.
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Empty(t, logger.Find("Threshold Log: "))
+}
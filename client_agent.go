@@ -0,0 +1,543 @@
+package cbcolumnar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/couchbase/gocbcore/v10"
+)
+
+// endpointDiscoveryInterval is how long the endpoints discovered from the cluster config are used for before they
+// are discovered again.
+const endpointDiscoveryInterval = 10 * time.Second
+
+var errEndpointDiscovery = errors.New("credentials are not provided while discovering endpoints")
+
+// endpointDiscoverer discovers the analytics endpoints of the cluster, updating the endpoints known to httpClient.
+type endpointDiscoverer interface {
+	// DiscoverEndpoints discovers the endpoints unless they were discovered recently.
+	DiscoverEndpoints(ctx context.Context) error
+}
+
+// agentEndpoints learns the analytics endpoints of the cluster from the agent. gocbcore does not expose its cluster
+// config, but it requests credentials from the auth provider for each analytics endpoint before sending a request
+// to it, so the endpoint of every query sent by the agent is recorded.
+//
+// When every endpoint is needed, such as for Ping, they are discovered by sending a query through the agent while
+// credentials for the analytics service are refused. Each time credentials are refused the agent moves on to
+// another endpoint from the cluster config without sending anything, until there are none left and it fails with
+// gocbcore.ErrServiceNotAvailable. Queries which are sent while endpoints are being discovered are refused in the
+// same way, so agentClient.Query sends them again once the discovery has finished.
+type agentEndpoints struct {
+	// onEndpoint is called with the endpoint of each query sent by the agent.
+	onEndpoint func(endpoint string)
+
+	lock        sync.Mutex
+	discovery   *endpointDiscovery
+	discoveries uint64
+}
+
+// endpointDiscovery collects the endpoints which the agent requested credentials for during a discovery.
+type endpointDiscovery struct {
+	endpoints []string
+	done      chan struct{}
+}
+
+func newAgentEndpoints(onEndpoint func(endpoint string)) *agentEndpoints {
+	return &agentEndpoints{
+		onEndpoint:  onEndpoint,
+		lock:        sync.Mutex{},
+		discovery:   nil,
+		discoveries: 0,
+	}
+}
+
+// Requested records that the agent requested credentials for the endpoint, returning false if the credentials
+// must be refused because the endpoints are being discovered.
+func (e *agentEndpoints) Requested(endpoint string) bool {
+	e.lock.Lock()
+
+	if e.discovery != nil {
+		if !slices.Contains(e.discovery.endpoints, endpoint) {
+			e.discovery.endpoints = append(e.discovery.endpoints, endpoint)
+		}
+		e.lock.Unlock()
+
+		return false
+	}
+	e.lock.Unlock()
+
+	if e.onEndpoint != nil {
+		e.onEndpoint(endpoint)
+	}
+
+	return true
+}
+
+// Discoveries returns the number of discoveries which have been started, which is passed to WaitForDiscovery.
+func (e *agentEndpoints) Discoveries() uint64 {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return e.discoveries
+}
+
+// WaitForDiscovery waits for the current discovery to finish, returning whether any discovery was started after
+// Discoveries returned since. If it returns true then a query which failed with gocbcore.ErrServiceNotAvailable
+// may have been refused credentials by the discovery, and should be sent again.
+func (e *agentEndpoints) WaitForDiscovery(ctx context.Context, since uint64) bool {
+	e.lock.Lock()
+	discovery := e.discovery
+	started := e.discoveries != since
+	e.lock.Unlock()
+
+	if discovery == nil {
+		return started
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-discovery.done:
+		return true
+	}
+}
+
+// discover refuses credentials for the analytics service while fn runs, returning the endpoints which the agent
+// requested credentials for.
+func (e *agentEndpoints) discover(fn func()) []string {
+	discovery := &endpointDiscovery{
+		endpoints: nil,
+		done:      make(chan struct{}),
+	}
+
+	e.lock.Lock()
+	e.discovery = discovery
+	e.discoveries++
+	e.lock.Unlock()
+
+	fn()
+
+	e.lock.Lock()
+	e.discovery = nil
+	e.lock.Unlock()
+
+	close(discovery.done)
+
+	return discovery.endpoints
+}
+
+// agentClient sends queries via the gocbcore agent, and provides httpClient with the analytics endpoints from the
+// cluster config for the requests which are sent directly, such as those for query handles and diagnostics.
+type agentClient struct {
+	http      *httpClient
+	endpoints *agentEndpoints
+	logger    *clusterLogger
+
+	// lock guards the agent and the config it was created with, which are replaced when the agent is re-seeded.
+	lock    sync.Mutex
+	agent   *agentRef
+	config  gocbcore.ColumnarAgentConfig
+	retired map[*agentRef]struct{}
+	closed  bool
+
+	discoveryLock sync.Mutex
+	discoveredAt  time.Time
+}
+
+// agentRef tracks the queries being dispatched by an agent, so that once the agent has been replaced it is only
+// closed after those queries have been sent.
+type agentRef struct {
+	agent     *gocbcore.ColumnarAgent
+	inFlight  sync.WaitGroup
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newAgentRef(config gocbcore.ColumnarAgentConfig) (*agentRef, error) {
+	agent, err := gocbcore.CreateColumnarAgent(&config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create agent: %s", err) // nolint: err113, errorlint
+	}
+
+	return &agentRef{
+		agent:     agent,
+		inFlight:  sync.WaitGroup{},
+		closeOnce: sync.Once{},
+		closeErr:  nil,
+	}, nil
+}
+
+func (r *agentRef) Close() error {
+	r.closeOnce.Do(func() {
+		r.closeErr = r.agent.Close()
+	})
+
+	return r.closeErr
+}
+
+// newAgentClient creates the client, endpoints must be the same as those given to the auth provider in config.
+func newAgentClient(config gocbcore.ColumnarAgentConfig, endpoints *agentEndpoints, http *httpClient,
+	logger *clusterLogger) (*agentClient, error) {
+	agent, err := newAgentRef(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &agentClient{
+		http:          http,
+		endpoints:     endpoints,
+		logger:        logger,
+		lock:          sync.Mutex{},
+		agent:         agent,
+		config:        config,
+		retired:       make(map[*agentRef]struct{}),
+		closed:        false,
+		discoveryLock: sync.Mutex{},
+		discoveredAt:  time.Time{},
+	}, nil
+}
+
+// acquire returns the current agent, which must be released by calling inFlight.Done once the query has been sent.
+func (c *agentClient) acquire() *agentRef {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.agent.inFlight.Add(1)
+
+	return c.agent
+}
+
+// agentRows are the rows of a query sent by the agent.
+type agentRows struct {
+	*gocbcore.ColumnarRowReader
+
+	// endpoint is the endpoint which the agent sent the query to.
+	endpoint string
+
+	// release marks the connection which the rows are read from as no longer in use.
+	release func()
+}
+
+// NextRow returns the next row, releasing the connection once there are no more rows.
+func (r *agentRows) NextRow() []byte {
+	row := r.ColumnarRowReader.NextRow()
+	if row == nil {
+		r.release()
+	}
+
+	return row
+}
+
+// Close closes the rows, releasing the connection.
+func (r *agentRows) Close() error {
+	r.release()
+
+	return r.ColumnarRowReader.Close() // nolint: wrapcheck
+}
+
+// Query sends the query via the agent. The agent does not create spans itself, so the dispatch span is created
+// around the call to the agent and covers every attempt the agent makes to send the query.
+func (c *agentClient) Query(ctx context.Context, opts gocbcore.ColumnarQueryOptions) (*agentRows, error) {
+	agent := c.acquire()
+	defer agent.inFlight.Done()
+
+	c.lock.Lock()
+	idleTimeout := c.config.HTTPConfig.IdleConnectionTimeout
+	c.lock.Unlock()
+
+	ctx, span := c.http.tracer.RequestSpan(ctx, spanNameDispatchToServer)
+	defer span.End()
+
+	var hostPort string
+
+	release := func() {}
+
+	// The agent only ever connects to the analytics service using TLS, and each attempt to send the query gets
+	// a connection from the agent's transport, so the last connection is to the endpoint which handled the query.
+	// The connection is reported by Cluster.Diagnostics until the rows have been read.
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(requested string) {
+			hostPort = requested
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			release()
+			release = c.http.conns.Observe("https://"+hostPort, info.Conn, idleTimeout)
+		},
+	})
+
+	var res *gocbcore.ColumnarRowReader
+
+	var err error
+
+	for {
+		discoveries := c.endpoints.Discoveries()
+
+		res, err = agent.agent.Query(ctx, opts)
+		if !errors.Is(err, gocbcore.ErrServiceNotAvailable) || !c.endpoints.WaitForDiscovery(ctx, discoveries) {
+			break
+		}
+
+		c.logger.Debugf("Resending query which was refused credentials while endpoints were discovered")
+	}
+
+	var endpoint string
+
+	if hostPort != "" {
+		endpoint = "https://" + hostPort
+
+		if host, portStr, splitErr := net.SplitHostPort(hostPort); splitErr == nil {
+			span.SetAttribute(spanAttribNetPeerName, host)
+
+			if port, convErr := strconv.Atoi(portStr); convErr == nil {
+				span.SetAttribute(spanAttribNetPeerPort, port)
+			}
+		}
+	}
+
+	if err != nil {
+		release()
+
+		var coreErr *gocbcore.ColumnarError
+		if errors.As(err, &coreErr) && coreErr.HTTPResponseCode > 0 {
+			span.SetAttribute(spanAttribHTTPStatusCode, coreErr.HTTPResponseCode)
+		}
+
+		span.RecordError(err)
+
+		return nil, err // nolint: wrapcheck
+	}
+
+	// The agent only returns a row reader for a response with a 200 status code.
+	span.SetAttribute(spanAttribHTTPStatusCode, http.StatusOK)
+
+	return &agentRows{
+		ColumnarRowReader: res,
+		endpoint:          endpoint,
+		release:           release,
+	}, nil
+}
+
+// QueryViaHTTP sends the query to one of the analytics endpoints using httpClient rather than the agent. gocbcore
+// always sends a username and password with a query, so queries which authenticate using a client certificate
+// are sent this way. The endpoints are discovered first if none are known yet.
+func (c *agentClient) QueryViaHTTP(ctx context.Context, opts gocbcore.ColumnarQueryOptions) (*httpRowReader, error) {
+	statement, _ := opts.Payload["statement"].(string)
+
+	body, err := json.Marshal(opts.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query payload: %s", err) // nolint: err113, errorlint
+	}
+
+	if len(c.http.Endpoints()) == 0 {
+		err := c.DiscoverEndpoints(ctx)
+		if err != nil {
+			return nil, newHTTPError(err, statement, "", 0)
+		}
+	}
+
+	endpoint, err := c.http.RandomEndpoint()
+	if err != nil {
+		return nil, newHTTPError(err, statement, "", 0)
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+
+	if opts.Priority != nil {
+		header.Set("Analytics-Priority", strconv.Itoa(*opts.Priority))
+	}
+
+	resp, err := c.http.Do(ctx, http.MethodPost, endpoint, "/api/v1/request", header, body)
+	if err != nil {
+		return nil, newHTTPError(err, statement, endpoint, 0)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, err := c.http.readBody(resp, statement, endpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, newHTTPResponseError(respBody, statement, endpoint, resp.StatusCode)
+	}
+
+	return newHTTPRowReader(resp.Body, statement, endpoint, resp.StatusCode, c.logger)
+}
+
+// SeedEndpoints returns the addresses which the agent bootstraps against, as endpoints.
+func (c *agentClient) SeedEndpoints() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	endpoints := make([]string, len(c.config.SeedConfig.MemdAddrs))
+	for i, addr := range c.config.SeedConfig.MemdAddrs {
+		endpoints[i] = "couchbases://" + addr
+	}
+
+	return endpoints
+}
+
+// UpdateSeedAddresses re-seeds the agent by creating a new agent for the addresses and replacing the current
+// agent with it. The current agent is closed once the queries it is dispatching have been sent, queries which
+// are already streaming their results are unaffected. If the new agent cannot be created then the current agent
+// continues to be used.
+func (c *agentClient) UpdateSeedAddresses(addrs []address) {
+	c.lock.Lock()
+	config := c.config
+	c.lock.Unlock()
+
+	config.SeedConfig.MemdAddrs = memdAddresses(addrs)
+
+	agent, err := newAgentRef(config)
+	if err != nil {
+		c.logger.WarnAttrs("Failed to re-seed agent, continuing to use the existing addresses", c.logger.ErrAttr(err))
+
+		return
+	}
+
+	c.lock.Lock()
+
+	if c.closed {
+		c.lock.Unlock()
+
+		err = agent.Close()
+		if err != nil {
+			c.logger.Debugf("Failed to close agent: %s", err)
+		}
+
+		return
+	}
+
+	previous := c.agent
+	c.agent = agent
+	c.config = config
+	c.retired[previous] = struct{}{}
+	c.lock.Unlock()
+
+	c.logger.Debugf("Re-seeded agent with %d addresses", len(addrs))
+
+	go func() {
+		previous.inFlight.Wait()
+
+		err := previous.Close()
+		if err != nil {
+			c.logger.Debugf("Failed to close agent: %s", err)
+		}
+
+		c.lock.Lock()
+		delete(c.retired, previous)
+		c.lock.Unlock()
+	}()
+}
+
+// DiscoverEndpoints replaces the endpoints known to httpClient with those in the cluster config of the agent, unless
+// they were discovered within endpointDiscoveryInterval. See agentEndpoints for how the endpoints are discovered.
+func (c *agentClient) DiscoverEndpoints(ctx context.Context) error {
+	c.discoveryLock.Lock()
+	defer c.discoveryLock.Unlock()
+
+	if !c.discoveredAt.IsZero() && time.Since(c.discoveredAt) < endpointDiscoveryInterval {
+		return nil
+	}
+
+	c.lock.Lock()
+	timeout := c.config.ConnectTimeout
+	c.lock.Unlock()
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	agent := c.acquire()
+	defer agent.inFlight.Done()
+
+	var err error
+
+	endpoints := c.endpoints.discover(func() {
+		var res *gocbcore.ColumnarRowReader
+
+		// Nothing is sent, as credentials are refused for every endpoint.
+		res, err = agent.agent.Query(ctx, gocbcore.ColumnarQueryOptions{
+			Payload:      map[string]interface{}{},
+			Priority:     nil,
+			User:         "",
+			TraceContext: nil,
+		})
+		if err == nil {
+			_ = res.Close()
+		}
+	})
+
+	if len(endpoints) == 0 {
+		var coreErr *gocbcore.ColumnarError
+		if errors.As(err, &coreErr) {
+			err = coreErr.InnerError
+		}
+
+		if err == nil || errors.Is(err, gocbcore.ErrServiceNotAvailable) {
+			err = errNoEndpoints
+		}
+
+		return fmt.Errorf("failed to discover analytics endpoints: %w", err)
+	}
+
+	c.logger.Debugf("Discovered %d analytics endpoints", len(endpoints))
+
+	c.http.SetEndpoints(endpoints)
+	c.discoveredAt = time.Now()
+
+	return nil
+}
+
+// Close closes the agent, along with any agents which have been replaced but not yet closed.
+func (c *agentClient) Close() error {
+	c.lock.Lock()
+	c.closed = true
+	agents := []*agentRef{c.agent}
+
+	for agent := range c.retired {
+		agents = append(agents, agent)
+	}
+	c.lock.Unlock()
+
+	var firstErr error
+
+	for _, agent := range agents {
+		err := agent.Close()
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to close agent: %s", err) // nolint: err113, errorlint
+		}
+	}
+
+	return firstErr
+}
+
+// memdAddresses returns the addresses used to bootstrap the agent, using the default port for any address which
+// does not specify a port.
+func memdAddresses(addrs []address) []string {
+	memdAddrs := make([]string, len(addrs))
+
+	for i, addr := range addrs {
+		port := addr.Port
+		if port == -1 {
+			port = 11207
+		}
+
+		memdAddrs[i] = net.JoinHostPort(addr.Host, strconv.Itoa(port))
+	}
+
+	return memdAddrs
+}
@@ -0,0 +1,138 @@
+package cbcolumnar
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/couchbase/gocbcore/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentClientUpdateSeedAddresses(t *testing.T) {
+	logger := newClusterLogger("cluster-1", &formatLogger{messages: nil}, nil)
+	endpoints := newAgentEndpoints(nil)
+
+	client, err := newAgentClient(gocbcore.ColumnarAgentConfig{
+		UserAgent:      Identifier(),
+		ConnectTimeout: time.Second,
+		SeedConfig: gocbcore.ColumnarSeedConfig{
+			MemdAddrs: memdAddresses([]address{{Host: "127.0.0.1", Port: -1}}),
+			SRVRecord: nil,
+		},
+		SecurityConfig: gocbcore.ColumnarSecurityConfig{
+			TLSRootCAProvider: func() *x509.CertPool {
+				return nil
+			},
+			CipherSuite: nil,
+			Auth: gocbcoreAuthProvider{
+				provider:  NewStaticCredentialProvider(NewCredential("username", "password")),
+				timeout:   time.Second,
+				endpoints: endpoints,
+			},
+		},
+		ConfigPollerConfig: gocbcore.ColumnarConfigPollerConfig{
+			CccpMaxWait:    0,
+			CccpPollPeriod: 0,
+		},
+		KVConfig: gocbcore.ColumnarKVConfig{
+			ConnectTimeout:       time.Second,
+			ServerWaitBackoff:    0,
+			ConnectionBufferSize: 0,
+		},
+		HTTPConfig: gocbcore.ColumnarHTTPConfig{
+			MaxIdleConns:          0,
+			MaxIdleConnsPerHost:   0,
+			MaxConnsPerHost:       0,
+			IdleConnectionTimeout: 0,
+		},
+	}, endpoints, nil, logger)
+	require.NoError(t, err)
+
+	previous := client.acquire()
+
+	client.UpdateSeedAddresses([]address{{Host: "127.0.0.2", Port: 11208}, {Host: "::1", Port: -1}})
+
+	client.lock.Lock()
+	assert.NotSame(t, previous, client.agent)
+	assert.Equal(t, []string{"127.0.0.2:11208", "[::1]:11207"}, client.config.SeedConfig.MemdAddrs)
+	assert.Contains(t, client.retired, previous)
+	client.lock.Unlock()
+
+	// The replaced agent is only closed once the query it is dispatching has been sent.
+	previous.inFlight.Done()
+
+	assert.Eventually(t, func() bool {
+		client.lock.Lock()
+		defer client.lock.Unlock()
+
+		return len(client.retired) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, client.Close())
+}
+
+func TestAgentEndpointsDiscover(t *testing.T) {
+	var recorded []string
+
+	endpoints := newAgentEndpoints(func(endpoint string) {
+		recorded = append(recorded, endpoint)
+	})
+
+	// Credentials are provided for queries, recording their endpoints.
+	assert.True(t, endpoints.Requested("https://10.0.0.1:18095"))
+	assert.Equal(t, []string{"https://10.0.0.1:18095"}, recorded)
+
+	since := endpoints.Discoveries()
+	assert.False(t, endpoints.WaitForDiscovery(context.Background(), since))
+
+	waited := make(chan bool, 1)
+
+	discovered := endpoints.discover(func() {
+		assert.False(t, endpoints.Requested("https://10.0.0.2:18095"))
+		assert.False(t, endpoints.Requested("https://10.0.0.1:18095"))
+		assert.False(t, endpoints.Requested("https://10.0.0.2:18095"))
+
+		// A query refused credentials during the discovery is sent again once it has finished.
+		go func() {
+			waited <- endpoints.WaitForDiscovery(context.Background(), since)
+		}()
+	})
+
+	assert.Equal(t, []string{"https://10.0.0.2:18095", "https://10.0.0.1:18095"}, discovered)
+	assert.Equal(t, []string{"https://10.0.0.1:18095"}, recorded)
+	assert.True(t, <-waited)
+
+	// A query which started after the discovery finished was not refused by it.
+	assert.False(t, endpoints.WaitForDiscovery(context.Background(), endpoints.Discoveries()))
+	assert.True(t, endpoints.Requested("https://10.0.0.2:18095"))
+}
+
+func TestGocbcoreAuthProviderCertificate(t *testing.T) {
+	var recorded []string
+
+	auth := gocbcoreAuthProvider{
+		provider:  NewStaticCredentialProvider(NewTLSCertificateCredential(tls.Certificate{})),
+		timeout:   time.Second,
+		endpoints: newAgentEndpoints(func(endpoint string) { recorded = append(recorded, endpoint) }),
+	}
+
+	// Key-value connections skip SASL authentication.
+	creds, err := auth.Credentials(gocbcore.AuthCredsRequest{
+		Service:  gocbcore.MemdService,
+		Endpoint: "couchbases://10.0.0.1:11207",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []gocbcore.UserPassPair{{Username: "", Password: ""}}, creds)
+
+	// The agent never sends a query, as it would send an empty username and password with it.
+	_, err = auth.Credentials(gocbcore.AuthCredsRequest{
+		Service:  gocbcore.CbasService,
+		Endpoint: "https://10.0.0.1:18095",
+	})
+	require.ErrorIs(t, err, errCertificateCredentials)
+	assert.Equal(t, []string{"https://10.0.0.1:18095"}, recorded)
+}
@@ -4,7 +4,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
-	"os"
+	"net"
 	"time"
 
 	"github.com/couchbase/gocbcore/v10"
@@ -13,6 +13,8 @@ import (
 
 type clusterClient interface {
 	QueryClient() queryClient
+	QueryHandleClient() queryHandleClient
+	DiagnosticsClient() diagnosticsClient
 	Database(name string) databaseClient
 
 	Close() error
@@ -25,7 +27,7 @@ type address struct {
 
 type clusterClientOptions struct {
 	Spec                                 gocbconnstr.ConnSpec
-	Credential                           *Credential
+	CredentialProvider                   CredentialProvider
 	ConnectTimeout                       time.Duration
 	ServerQueryTimeout                   time.Duration
 	TrustOnly                            TrustOnly
@@ -34,114 +36,236 @@ type clusterClientOptions struct {
 	DisableSrv                           bool
 	Addresses                            []address
 	Unmarshaler                          Unmarshaler
-}
 
-func newClusterClient(opts clusterClientOptions) (clusterClient, error) {
-	return newGocbcoreClusterClient(opts)
-}
+	// Tracer when set is used to create spans for queries and the requests which send them.
+	Tracer RequestTracer
 
-type gocbcoreClusterClient struct {
-	agent *gocbcore.ColumnarAgent
+	// Meter when set is used to record metrics for queries.
+	Meter Meter
 
-	serverQueryTimeout time.Duration
-	unmarshaler        Unmarshaler
+	// EnableThresholdLogging enables logging the queries which take longer than ThresholdLoggingThreshold
+	// every ThresholdLoggingInterval.
+	EnableThresholdLogging     bool
+	ThresholdLoggingThreshold  time.Duration
+	ThresholdLoggingInterval   time.Duration
+	ThresholdLoggingSampleSize int
+
+	// SrvRefreshInterval when greater than zero causes the SRV record to be re-resolved at the interval,
+	// updating the addresses used by the client.
+	SrvRefreshInterval time.Duration
+
+	// RecordPath when set causes all queries to be recorded to the golden file at the path.
+	RecordPath string
+
+	// ReplayPath when set causes queries to be replayed from the golden file at the path, rather
+	// than connecting to the cluster.
+	ReplayPath string
+
+	// Logger is used for all messages relating to the cluster.
+	Logger *clusterLogger
 }
 
-func newGocbcoreClusterClient(opts clusterClientOptions) (*gocbcoreClusterClient, error) {
-	addresses := make([]string, len(opts.Addresses))
+func newClusterClient(opts clusterClientOptions) (clusterClient, error) {
+	if opts.ReplayPath != "" {
+		return newReplayClusterClient(opts.ReplayPath, opts.Unmarshaler, opts.Logger)
+	}
 
-	for i, addr := range opts.Addresses {
-		port := addr.Port
-		if port == -1 {
-			port = 11207
+	caProvider, watcher, err := newTLSRootCAProvider(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var client clusterClient
+
+	client, err = newGocbcoreClusterClient(opts, caProvider)
+	if err != nil {
+		if watcher != nil {
+			watcher.Close()
 		}
 
-		addresses[i] = fmt.Sprintf("%s:%d", addr.Host, port)
+		return nil, err
 	}
 
-	var srvRecord *gocbcore.SRVRecord
+	if opts.SrvRefreshInterval > 0 && !opts.DisableSrv {
+		if updater, ok := client.(seedAddressUpdater); ok {
+			client = &srvRefreshingClusterClient{
+				clusterClient: client,
+				refresher: newSrvRefresher(opts.Spec.Addresses[0].Host, opts.SrvRefreshInterval, opts.ConnectTimeout,
+					net.DefaultResolver.LookupSRV, opts.Addresses, updater.UpdateSeedAddresses, opts.Logger),
+			}
+		}
+	}
 
-	if !opts.DisableSrv {
-		var host string
-		if len(opts.Addresses) > 0 {
-			host = opts.Addresses[0].Host
+	if opts.Tracer != nil || opts.Meter != nil || opts.EnableThresholdLogging {
+		var thresholdLog *thresholdLogger
+		if opts.EnableThresholdLogging {
+			thresholdLog = newThresholdLogger(opts.ThresholdLoggingThreshold, opts.ThresholdLoggingInterval,
+				opts.ThresholdLoggingSampleSize, opts.Logger)
 		}
 
-		srvRecord = &gocbcore.SRVRecord{
-			Proto:  "tcp",
-			Scheme: "couchbases",
-			Host:   host,
+		client = newInstrumentedClusterClient(client, opts.Tracer, opts.Meter, thresholdLog, opts.Logger)
+	}
+
+	if watcher != nil {
+		client = &pemFileWatchingClusterClient{
+			clusterClient: client,
+			watcher:       watcher,
 		}
 	}
 
+	if opts.RecordPath != "" {
+		return newRecordingClusterClient(client, opts.RecordPath), nil
+	}
+
+	return client, nil
+}
+
+// newTLSRootCAProvider creates the function used to fetch the root CAs to verify server certificates against.
+// If server certificate verification is disabled then the function returns nil.
+// If the root CAs are reloaded from a file then the watcher for the file is also returned, which must be closed
+// once it is no longer needed.
+func newTLSRootCAProvider(opts clusterClientOptions) (func() *x509.CertPool, *pemFileWatcher, error) {
+	if opts.DisableServerCertificateVerification != nil && *opts.DisableServerCertificateVerification {
+		return func() *x509.CertPool {
+			return nil
+		}, nil, nil
+	}
+
 	trustOnly := opts.TrustOnly
 	if trustOnly == nil {
 		trustOnly = TrustOnlyCapella{}
 	}
 
-	var caProvider func() *x509.CertPool
+	var pool *x509.CertPool
 
 	switch to := trustOnly.(type) {
-	case TrustOnlyCapella:
-		pool := x509.NewCertPool()
-		pool.AppendCertsFromPEM(capellaRootCA)
-
-		caProvider = func() *x509.CertPool {
-			return pool
-		}
+	case TrustOnlyCertificates:
+		return func() *x509.CertPool {
+			return to.Certificates
+		}, nil, nil
 	case TrustOnlySystem:
-		pool, err := x509.SystemCertPool()
+		systemPool, err := x509.SystemCertPool()
 		if err != nil {
-			return nil, fmt.Errorf("failed to read system cert pool %w", err)
+			return nil, nil, fmt.Errorf("failed to read system cert pool %w", err)
 		}
 
-		caProvider = func() *x509.CertPool {
-			return pool
-		}
+		pool = systemPool
 	case TrustOnlyPemFile:
-		data, err := os.ReadFile(to.Path)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read pem file %w", err)
+		if to.ReloadInterval > 0 {
+			watcher, err := newPemFileWatcher(to.Path, to.ReloadInterval, opts.Logger)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return watcher.Pool, watcher, nil
 		}
 
-		pool := x509.NewCertPool()
-		pool.AppendCertsFromPEM(data)
+		pool = x509.NewCertPool()
 
-		caProvider = func() *x509.CertPool {
-			return pool
+		_, err := appendTrustedCertificates(pool, to, opts.Logger)
+		if err != nil {
+			return nil, nil, err
 		}
-	case TrustOnlyPemString:
-		pool := x509.NewCertPool()
-		pool.AppendCertsFromPEM([]byte(to.Pem))
-
-		caProvider = func() *x509.CertPool {
-			return pool
+	case TrustOnlyCombined:
+		combinedPool, err := newCombinedCertPool(to, opts.Logger)
+		if err != nil {
+			return nil, nil, err
 		}
-	case TrustOnlyCertificates:
-		caProvider = func() *x509.CertPool {
-			return to.Certificates
+
+		pool = combinedPool
+	default:
+		pool = x509.NewCertPool()
+
+		_, err := appendTrustedCertificates(pool, to, opts.Logger)
+		if err != nil {
+			return nil, nil, err
 		}
 	}
 
-	if opts.DisableServerCertificateVerification != nil && *opts.DisableServerCertificateVerification {
-		caProvider = func() *x509.CertPool {
-			return nil
+	return func() *x509.CertPool {
+		return pool
+	}, nil, nil
+}
+
+// pemFileWatchingClusterClient wraps a clusterClient, closing the watcher for the trusted certificates when
+// the client is closed.
+type pemFileWatchingClusterClient struct {
+	clusterClient
+	watcher *pemFileWatcher
+}
+
+func (c *pemFileWatchingClusterClient) Close() error {
+	c.watcher.Close()
+
+	return c.clusterClient.Close()
+}
+
+// seedAddressUpdater is implemented by cluster clients which can have their seed addresses updated after
+// they are created.
+type seedAddressUpdater interface {
+	UpdateSeedAddresses(addrs []address)
+}
+
+// srvRefreshingClusterClient stops the SRV refresher when the cluster client is closed.
+type srvRefreshingClusterClient struct {
+	clusterClient
+	refresher *srvRefresher
+}
+
+func (c *srvRefreshingClusterClient) Close() error {
+	c.refresher.Close()
+
+	return c.clusterClient.Close()
+}
+
+type gocbcoreClusterClient struct {
+	agent        *agentClient
+	httpClient   *httpClient
+	handleClient queryHandleClient
+	diagClient   diagnosticsClient
+	credentials  CredentialProvider
+	logger       *clusterLogger
+
+	serverQueryTimeout time.Duration
+	unmarshaler        Unmarshaler
+}
+
+func newGocbcoreClusterClient(opts clusterClientOptions, caProvider func() *x509.CertPool) (*gocbcoreClusterClient, error) {
+	var srvRecord *gocbcore.SRVRecord
+
+	if !opts.DisableSrv {
+		var host string
+		if len(opts.Addresses) > 0 {
+			host = opts.Addresses[0].Host
+		}
+
+		srvRecord = &gocbcore.SRVRecord{
+			Proto:  "tcp",
+			Scheme: "couchbases",
+			Host:   host,
 		}
 	}
 
-	coreOpts := &gocbcore.ColumnarAgentConfig{
+	var httpCli *httpClient
+
+	endpoints := newAgentEndpoints(func(endpoint string) {
+		httpCli.AddEndpoint(endpoint)
+	})
+
+	coreOpts := gocbcore.ColumnarAgentConfig{
 		UserAgent:      Identifier(),
 		ConnectTimeout: opts.ConnectTimeout,
 		SeedConfig: gocbcore.ColumnarSeedConfig{
-			MemdAddrs: addresses,
+			MemdAddrs: memdAddresses(opts.Addresses),
 			SRVRecord: srvRecord,
 		},
 		SecurityConfig: gocbcore.ColumnarSecurityConfig{
 			TLSRootCAProvider: caProvider,
 			CipherSuite:       opts.CipherSuites,
-			Auth: gocbcore.PasswordAuthProvider{
-				Username: opts.Credential.UsernamePassword.Username,
-				Password: opts.Credential.UsernamePassword.Password,
+			Auth: gocbcoreAuthProvider{
+				provider:  opts.CredentialProvider,
+				timeout:   opts.ConnectTimeout,
+				endpoints: endpoints,
 			},
 		},
 		ConfigPollerConfig: gocbcore.ColumnarConfigPollerConfig{
@@ -161,31 +285,64 @@ func newGocbcoreClusterClient(opts clusterClientOptions) (*gocbcoreClusterClient
 		},
 	}
 
-	agent, err := gocbcore.CreateColumnarAgent(coreOpts)
+	httpCli = newHTTPClient(httpClientOptions{
+		Endpoints:          nil,
+		CredentialProvider: opts.CredentialProvider,
+		TLSRootCAProvider:  caProvider,
+		CipherSuites:       opts.CipherSuites,
+		ConnectTimeout:     opts.ConnectTimeout,
+		UserAgent:          Identifier(),
+		Tracer:             opts.Tracer,
+		Logger:             opts.Logger,
+	})
+
+	agent, err := newAgentClient(coreOpts, endpoints, httpCli, opts.Logger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create agent: %s", err) // nolint: err113, errorlint
+		return nil, err
+	}
+
+	seedSource := EndpointSourceSrv
+	if opts.DisableSrv {
+		seedSource = EndpointSourceConfigured
 	}
 
 	return &gocbcoreClusterClient{
 		agent:              agent,
+		httpClient:         httpCli,
+		handleClient:       newHTTPQueryHandleClient(httpCli, agent, opts.Unmarshaler),
+		diagClient:         newHTTPDiagnosticsClient(httpCli, agent, agent, seedSource, opts.ConnectTimeout),
+		credentials:        opts.CredentialProvider,
+		logger:             opts.Logger,
 		serverQueryTimeout: opts.ServerQueryTimeout,
 		unmarshaler:        opts.Unmarshaler,
 	}, nil
 }
 
+// UpdateSeedAddresses re-seeds the agent with the addresses.
+func (c *gocbcoreClusterClient) UpdateSeedAddresses(addrs []address) {
+	c.agent.UpdateSeedAddresses(addrs)
+}
+
 func (c *gocbcoreClusterClient) Database(name string) databaseClient {
-	return newGocbcoreDatabaseClient(c.agent, name, c.serverQueryTimeout, c.unmarshaler)
+	return newGocbcoreDatabaseClient(c.agent, c.handleClient, c.credentials, c.logger, name, c.serverQueryTimeout,
+		c.unmarshaler)
 }
 
 func (c *gocbcoreClusterClient) QueryClient() queryClient {
-	return newGocbcoreQueryClient(c.agent, c.serverQueryTimeout, c.unmarshaler, nil)
+	return newGocbcoreQueryClient(c.agent, c.handleClient, c.credentials, c.logger, c.serverQueryTimeout, c.unmarshaler,
+		nil)
+}
+
+func (c *gocbcoreClusterClient) DiagnosticsClient() diagnosticsClient {
+	return c.diagClient
+}
+
+func (c *gocbcoreClusterClient) QueryHandleClient() queryHandleClient {
+	return c.handleClient
 }
 
 func (c *gocbcoreClusterClient) Close() error {
-	err := c.agent.Close()
-	if err != nil {
-		return fmt.Errorf("failed to close agent: %s", err) // nolint: err113, errorlint
-	}
+	c.httpClient.Close()
 
-	return nil
+	return c.agent.Close()
 }
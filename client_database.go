@@ -2,8 +2,6 @@ package cbcolumnar
 
 import (
 	"time"
-
-	"github.com/couchbase/gocbcore/v10"
 )
 
 type databaseClient interface {
@@ -12,15 +10,22 @@ type databaseClient interface {
 }
 
 type gocbcoreDatabaseClient struct {
-	agent                     *gocbcore.ColumnarAgent
+	agent                     *agentClient
+	handleClient              queryHandleClient
+	credentials               CredentialProvider
+	logger                    *clusterLogger
 	name                      string
 	defaultServerQueryTimeout time.Duration
 	defaultUnmarshaler        Unmarshaler
 }
 
-func newGocbcoreDatabaseClient(agent *gocbcore.ColumnarAgent, name string, defaultServerQueryTimeout time.Duration, defaultUnmarshaler Unmarshaler) *gocbcoreDatabaseClient {
+func newGocbcoreDatabaseClient(agent *agentClient, handleClient queryHandleClient, credentials CredentialProvider,
+	logger *clusterLogger, name string, defaultServerQueryTimeout time.Duration, defaultUnmarshaler Unmarshaler) *gocbcoreDatabaseClient {
 	return &gocbcoreDatabaseClient{
 		agent:                     agent,
+		handleClient:              handleClient,
+		credentials:               credentials,
+		logger:                    logger,
 		name:                      name,
 		defaultServerQueryTimeout: defaultServerQueryTimeout,
 		defaultUnmarshaler:        defaultUnmarshaler,
@@ -32,5 +37,6 @@ func (c *gocbcoreDatabaseClient) Name() string {
 }
 
 func (c *gocbcoreDatabaseClient) Scope(name string) scopeClient {
-	return newGocbcoreScopeClient(c.agent, name, c.name, c.defaultServerQueryTimeout, c.defaultUnmarshaler)
+	return newGocbcoreScopeClient(c.agent, c.handleClient, c.credentials, c.logger, name, c.name, c.defaultServerQueryTimeout,
+		c.defaultUnmarshaler)
 }
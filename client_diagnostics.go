@@ -0,0 +1,240 @@
+package cbcolumnar
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type diagnosticsClient interface {
+	Ping(ctx context.Context) (*PingReport, error)
+	Diagnostics(ctx context.Context) (*DiagnosticsReport, error)
+}
+
+// seedEndpointsProvider provides the addresses which the client bootstraps against, as endpoints.
+type seedEndpointsProvider interface {
+	SeedEndpoints() []string
+}
+
+// httpDiagnosticsClient pings the analytics endpoints known to httpClient.
+type httpDiagnosticsClient struct {
+	http           *httpClient
+	discoverer     endpointDiscoverer
+	seeds          seedEndpointsProvider
+	seedSource     EndpointSource
+	defaultTimeout time.Duration
+}
+
+// newHTTPDiagnosticsClient creates a client which pings the endpoints known to http. If discoverer is not nil then
+// the endpoints are discovered from the cluster before pinging them, if they were not discovered recently.
+// The endpoints provided by seeds are included in diagnostics reports with seedSource as their source.
+func newHTTPDiagnosticsClient(http *httpClient, discoverer endpointDiscoverer, seeds seedEndpointsProvider,
+	seedSource EndpointSource, defaultTimeout time.Duration) *httpDiagnosticsClient {
+	return &httpDiagnosticsClient{
+		http:           http,
+		discoverer:     discoverer,
+		seeds:          seeds,
+		seedSource:     seedSource,
+		defaultTimeout: defaultTimeout,
+	}
+}
+
+func (c *httpDiagnosticsClient) Ping(ctx context.Context) (*PingReport, error) {
+	if c.discoverer != nil {
+		err := c.discoverer.DiscoverEndpoints(ctx)
+		if err != nil {
+			return nil, newHTTPError(err, "", "", 0)
+		}
+	}
+
+	endpoints := c.http.Endpoints()
+	if len(endpoints) == 0 {
+		return nil, newHTTPError(errNoEndpoints, "", "", 0)
+	}
+
+	report := &PingReport{
+		ID:        uuid.NewString(),
+		SDK:       Identifier(),
+		Endpoints: make([]EndpointPingReport, len(endpoints)),
+	}
+
+	var wg sync.WaitGroup
+
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			report.Endpoints[i] = c.pingEndpoint(ctx, endpoint)
+		}()
+	}
+
+	wg.Wait()
+
+	return report, nil
+}
+
+func (c *httpDiagnosticsClient) pingEndpoint(ctx context.Context, endpoint string) EndpointPingReport {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.defaultTimeout)
+		defer cancel()
+	}
+
+	report := EndpointPingReport{
+		Endpoint:   endpoint,
+		State:      PingStateOk,
+		Latency:    0,
+		StatusCode: 0,
+		TLS:        nil,
+		Error:      nil,
+	}
+
+	start := time.Now()
+
+	resp, err := c.http.Do(ctx, http.MethodGet, endpoint, "/admin/ping", nil, nil)
+	if err != nil {
+		report.Latency = time.Since(start)
+		report.State = PingStateError
+
+		if errors.Is(err, context.DeadlineExceeded) {
+			report.State = PingStateTimeout
+		}
+
+		report.Error = newHTTPError(err, "", endpoint, 0)
+
+		return report
+	}
+
+	respBody, err := c.http.readBody(resp, "", endpoint)
+	report.Latency = time.Since(start)
+	report.StatusCode = resp.StatusCode
+	report.TLS = newPingTLSState(resp.TLS)
+
+	if err != nil {
+		report.State = PingStateError
+		report.Error = err
+
+		return report
+	}
+
+	if resp.StatusCode != 200 {
+		report.State = PingStateError
+		report.Error = newHTTPResponseError(respBody, "", endpoint, resp.StatusCode)
+	}
+
+	return report
+}
+
+func (c *httpDiagnosticsClient) Diagnostics(ctx context.Context) (*DiagnosticsReport, error) {
+	seeds := c.seeds.SeedEndpoints()
+	endpoints := c.http.Endpoints()
+	conns := c.http.Connections()
+
+	report := &DiagnosticsReport{
+		ID:        uuid.NewString(),
+		SDK:       Identifier(),
+		Endpoints: make([]EndpointDiagnostics, 0, len(seeds)+len(endpoints)),
+	}
+
+	for _, seed := range seeds {
+		report.Endpoints = append(report.Endpoints, newEndpointDiagnostics(seed, c.seedSource))
+	}
+
+	for _, endpoint := range endpoints {
+		diag := newEndpointDiagnostics(endpoint, EndpointSourceClusterConfig)
+
+		for _, conn := range conns {
+			if conn.Endpoint == endpoint {
+				diag.Connections = append(diag.Connections, conn)
+			}
+		}
+
+		report.Endpoints = append(report.Endpoints, diag)
+	}
+
+	var wg sync.WaitGroup
+
+	for i := range report.Endpoints {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			report.Endpoints[i].ResolvedAddresses, report.Endpoints[i].ResolveError = c.resolveEndpoint(ctx,
+				report.Endpoints[i].Endpoint)
+		}()
+	}
+
+	wg.Wait()
+
+	return report, nil
+}
+
+func newEndpointDiagnostics(endpoint string, source EndpointSource) EndpointDiagnostics {
+	return EndpointDiagnostics{
+		Endpoint:          endpoint,
+		Source:            source,
+		ResolvedAddresses: nil,
+		ResolveError:      nil,
+		Connections:       []ConnectionDiagnostics{},
+	}
+}
+
+// resolveEndpoint resolves the host of the endpoint to its addresses.
+func (c *httpDiagnosticsClient) resolveEndpoint(ctx context.Context, endpoint string) ([]string, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.defaultTimeout)
+		defer cancel()
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse endpoint: %s", err) // nolint: err113, errorlint
+	}
+
+	host := u.Hostname()
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %s", host, err) // nolint: err113, errorlint
+	}
+
+	return addrs, nil
+}
+
+func newPingTLSState(state *tls.ConnectionState) *PingTLSState {
+	if state == nil {
+		return nil
+	}
+
+	tlsState := &PingTLSState{
+		Version:                tls.VersionName(state.Version),
+		CipherSuite:            tls.CipherSuiteName(state.CipherSuite),
+		ServerName:             state.ServerName,
+		PeerCertificateSubject: "",
+		PeerCertificateExpiry:  time.Time{},
+	}
+
+	if len(state.PeerCertificates) > 0 {
+		tlsState.PeerCertificateSubject = state.PeerCertificates[0].Subject.String()
+		tlsState.PeerCertificateExpiry = state.PeerCertificates[0].NotAfter
+	}
+
+	return tlsState
+}
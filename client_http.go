@@ -0,0 +1,342 @@
+package cbcolumnar
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/couchbase/gocbcore/v10"
+)
+
+type httpClientOptions struct {
+	CredentialProvider CredentialProvider
+	TLSRootCAProvider  func() *x509.CertPool
+	CipherSuites       []*tls.CipherSuite
+	ConnectTimeout     time.Duration
+	UserAgent          string
+	Tracer             RequestTracer
+	Logger             *clusterLogger
+
+	// Endpoints are the analytics endpoints which are initially known, further endpoints can be added once the
+	// client has been created.
+	Endpoints []string
+}
+
+// httpClient is used for talking to the REST endpoints of the analytics service which are not
+// exposed by gocbcore, such as those used for managing query handles. gocbcore does not expose the transport
+// used by the agent either, so the client has its own. The endpoints are provided by agentClient.
+type httpClient struct {
+	cli           *http.Client
+	endpointsLock sync.RWMutex
+	endpoints     []string
+	credentials   CredentialProvider
+	userAgent     string
+	tracer        RequestTracer
+	logger        *clusterLogger
+	conns         *connTracker
+}
+
+func newHTTPClient(opts httpClientOptions) *httpClient {
+	endpoints := slices.Clone(opts.Endpoints)
+	slices.Sort(endpoints)
+
+	suites := make([]uint16, len(opts.CipherSuites))
+	for i, suite := range opts.CipherSuites {
+		suites[i] = suite.ID
+	}
+
+	if len(suites) == 0 {
+		suites = nil
+	}
+
+	conns := newConnTracker()
+
+	var tracer RequestTracer = noopTracer{}
+	if opts.Tracer != nil {
+		tracer = opts.Tracer
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   opts.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := &http.Transport{
+		ForceAttemptHTTP2: true,
+		DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err // nolint: wrapcheck
+			}
+
+			tlsConfig := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				CipherSuites: suites,
+				ServerName:   host,
+			}
+
+			tlsConfig.GetClientCertificate = func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				credential, err := fetchCredential(info.Context(), opts.CredentialProvider)
+				if err != nil {
+					return nil, err
+				}
+
+				if credential.Certificate == nil {
+					return &tls.Certificate{}, nil
+				}
+
+				return credential.Certificate, nil
+			}
+
+			rootCAs := opts.TLSRootCAProvider()
+			if rootCAs == nil {
+				tlsConfig.InsecureSkipVerify = true // nolint: gosec
+			} else {
+				tlsConfig.RootCAs = rootCAs
+			}
+
+			tcpConn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err // nolint: wrapcheck
+			}
+
+			return conns.Track("https://"+addr, tcpConn, func(conn net.Conn) net.Conn {
+				return tls.Client(conn, tlsConfig)
+			}), nil
+		},
+		IdleConnTimeout: 1 * time.Second,
+	}
+
+	return &httpClient{
+		cli: &http.Client{
+			Transport: transport,
+		},
+		endpointsLock: sync.RWMutex{},
+		endpoints:     endpoints,
+		credentials:   opts.CredentialProvider,
+		userAgent:     opts.UserAgent,
+		tracer:        tracer,
+		logger:        opts.Logger,
+		conns:         conns,
+	}
+}
+
+var errNoEndpoints = errors.New("no analytics endpoints available")
+
+func (c *httpClient) RandomEndpoint() (string, error) {
+	endpoints := c.Endpoints()
+	if len(endpoints) == 0 {
+		return "", errNoEndpoints
+	}
+
+	return endpoints[rand.Intn(len(endpoints))], nil // #nosec G404
+}
+
+func (c *httpClient) Endpoints() []string {
+	c.endpointsLock.RLock()
+	defer c.endpointsLock.RUnlock()
+
+	return c.endpoints
+}
+
+// SetEndpoints replaces the known endpoints. Requests which are already in progress are unaffected.
+func (c *httpClient) SetEndpoints(endpoints []string) {
+	endpoints = slices.Clone(endpoints)
+	slices.Sort(endpoints)
+
+	c.endpointsLock.Lock()
+	c.endpoints = endpoints
+	c.endpointsLock.Unlock()
+}
+
+// AddEndpoint adds the endpoint to the known endpoints, if it is not already known.
+func (c *httpClient) AddEndpoint(endpoint string) {
+	c.endpointsLock.RLock()
+	_, found := slices.BinarySearch(c.endpoints, endpoint)
+	c.endpointsLock.RUnlock()
+
+	if found {
+		return
+	}
+
+	c.endpointsLock.Lock()
+	defer c.endpointsLock.Unlock()
+
+	idx, found := slices.BinarySearch(c.endpoints, endpoint)
+	if found {
+		return
+	}
+
+	c.endpoints = slices.Insert(slices.Clone(c.endpoints), idx, endpoint)
+}
+
+// Do sends the request to the endpoint. If the server rejects the credential then the credential provider
+// is refreshed, and if that results in a different credential then the request is retried once.
+func (c *httpClient) Do(ctx context.Context, method, endpoint, path string, header http.Header, body []byte) (*http.Response, error) {
+	resp, err := c.doOnce(ctx, method, endpoint, path, header, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized || !refreshCredential(ctx, c.credentials, c.logger) {
+		return resp, nil
+	}
+
+	closeErr := resp.Body.Close()
+	if closeErr != nil {
+		c.logger.Debugf("Failed to close response body: %s", closeErr)
+	}
+
+	c.logger.DebugAttrs("Retrying HTTP request after credential was refreshed", slog.String(logAttrEndpoint, endpoint))
+
+	return c.doOnce(ctx, method, endpoint, path, header, body)
+}
+
+func (c *httpClient) doOnce(ctx context.Context, method, endpoint, path string, header http.Header,
+	body []byte) (*http.Response, error) {
+	credential, err := fetchCredential(ctx, c.credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint+path, bodyReader)
+	if err != nil {
+		return nil, err // nolint: wrapcheck
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	req.Header.Set("User-Agent", c.userAgent)
+
+	if credential.UsernamePassword != nil {
+		req.SetBasicAuth(credential.UsernamePassword.Username, credential.UsernamePassword.Password)
+	}
+
+	ctx, span := c.tracer.RequestSpan(ctx, spanNameDispatchToServer)
+	defer span.End()
+
+	span.SetAttribute(spanAttribNetPeerName, req.URL.Hostname())
+	if port, err := strconv.Atoi(req.URL.Port()); err == nil {
+		span.SetAttribute(spanAttribNetPeerPort, port)
+	}
+
+	var conn *trackedConn
+
+	req = req.WithContext(httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if tc, ok := c.conns.Lookup(info.Conn); ok {
+				tc.inUse.Add(1)
+				conn = tc
+			}
+		},
+	}))
+
+	c.logger.SchedAttrs("Writing HTTP request", slog.String(logAttrEndpoint, req.URL.String()))
+
+	resp, err := c.cli.Do(req)
+	if err != nil {
+		if conn != nil {
+			conn.inUse.Add(-1)
+		}
+
+		span.RecordError(err)
+
+		return nil, err // nolint: wrapcheck
+	}
+
+	span.SetAttribute(spanAttribHTTPStatusCode, resp.StatusCode)
+
+	c.logger.DebugAttrs("Received HTTP response", slog.String(logAttrEndpoint, req.URL.String()),
+		slog.Int(logAttrStatusCode, resp.StatusCode))
+
+	if conn != nil {
+		resp.Body = &trackedBody{
+			ReadCloser: resp.Body,
+			conn:       conn,
+			closeOnce:  sync.Once{},
+		}
+	}
+
+	return resp, nil
+}
+
+// Connections returns the state of all connections which are currently open.
+func (c *httpClient) Connections() []ConnectionDiagnostics {
+	return c.conns.Snapshot()
+}
+
+func (c *httpClient) Close() {
+	c.cli.CloseIdleConnections()
+}
+
+// readBody reads and closes the body of the response, translating any errors.
+func (c *httpClient) readBody(resp *http.Response, statement, endpoint string) ([]byte, error) {
+	respBody, err := io.ReadAll(resp.Body)
+
+	closeErr := resp.Body.Close()
+	if closeErr != nil {
+		c.logger.Debugf("Failed to close response body: %s", closeErr)
+	}
+
+	if err != nil {
+		return nil, newHTTPError(fmt.Errorf("failed to read response body: %s", err), statement, endpoint, resp.StatusCode) // nolint: err113, errorlint
+	}
+
+	return respBody, nil
+}
+
+// newHTTPResponseError creates the error for a response with an unexpected status code, preferring any
+// errors contained within the response body.
+func newHTTPResponseError(respBody []byte, statement, endpoint string, statusCode int) error {
+	err := parseHTTPErrorResponse(respBody, statement, endpoint, statusCode)
+	if err != nil {
+		return err
+	}
+
+	return translateGocbcoreError(&gocbcore.ColumnarError{
+		InnerError:       fmt.Errorf("server returned unexpected status code %d", statusCode), // nolint: err113
+		Statement:        statement,
+		Errors:           nil,
+		LastErrorCode:    0,
+		LastErrorMsg:     "",
+		Endpoint:         endpoint,
+		ErrorText:        string(respBody),
+		HTTPResponseCode: statusCode,
+		WasNotDispatched: false,
+	})
+}
+
+// newHTTPError translates an error which occurred while sending a request or reading a response.
+func newHTTPError(err error, statement, endpoint string, statusCode int) error {
+	return translateGocbcoreError(&gocbcore.ColumnarError{
+		InnerError:       err,
+		Statement:        statement,
+		Errors:           nil,
+		LastErrorCode:    0,
+		LastErrorMsg:     "",
+		Endpoint:         endpoint,
+		ErrorText:        "",
+		HTTPResponseCode: statusCode,
+		WasNotDispatched: false,
+	})
+}
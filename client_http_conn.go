@@ -0,0 +1,257 @@
+package cbcolumnar
+
+import (
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// connTracker keeps track of the connections opened by httpClient, along with those observed being used by the
+// agent, so that they can be reported by Cluster.Diagnostics.
+type connTracker struct {
+	lock     sync.Mutex
+	conns    map[net.Conn]*trackedConn
+	observed map[net.Conn]*observedConn
+	nextID   uint64
+}
+
+func newConnTracker() *connTracker {
+	return &connTracker{
+		lock:     sync.Mutex{},
+		conns:    make(map[net.Conn]*trackedConn),
+		observed: make(map[net.Conn]*observedConn),
+		nextID:   0,
+	}
+}
+
+// Track wraps the connection to the endpoint so that its activity is recorded. The wrap function is used
+// to create the connection which is handed to the http.Transport, such as a TLS client for the connection,
+// and is the connection which is later passed to Lookup.
+func (t *connTracker) Track(endpoint string, conn net.Conn, wrap func(net.Conn) net.Conn) net.Conn {
+	now := time.Now()
+
+	tc := &trackedConn{
+		Conn:         conn,
+		tracker:      t,
+		endpoint:     endpoint,
+		connectedAt:  now,
+		lastActivity: atomic.Int64{},
+		inUse:        atomic.Int32{},
+		closeOnce:    sync.Once{},
+		outer:        nil,
+	}
+	tc.lastActivity.Store(now.UnixNano())
+
+	tc.outer = wrap(tc)
+
+	t.lock.Lock()
+	t.nextID++
+	tc.id = t.nextID
+	t.conns[tc.outer] = tc
+	t.lock.Unlock()
+
+	return tc.outer
+}
+
+// Observe records that a connection to the endpoint, which was opened by a transport which cannot be wrapped
+// such as the agent's, is being used by a request. The connection is reported as active until the returned
+// function is called. As the connection cannot be wrapped it is not known when it is closed, so it is reported
+// until it has been idle for longer than idleTimeout, the idle timeout of the transport which opened it.
+func (t *connTracker) Observe(endpoint string, conn net.Conn, idleTimeout time.Duration) func() {
+	now := time.Now()
+
+	t.lock.Lock()
+	t.removeExpired(now)
+
+	oc, ok := t.observed[conn]
+	if !ok {
+		t.nextID++
+		oc = &observedConn{
+			id:            t.nextID,
+			endpoint:      endpoint,
+			localAddress:  conn.LocalAddr().String(),
+			remoteAddress: conn.RemoteAddr().String(),
+			connectedAt:   now,
+			lastActivity:  now,
+			inUse:         0,
+			idleTimeout:   idleTimeout,
+		}
+		t.observed[conn] = oc
+	}
+
+	oc.inUse++
+	oc.lastActivity = now
+	t.lock.Unlock()
+
+	var releaseOnce sync.Once
+
+	return func() {
+		releaseOnce.Do(func() {
+			t.lock.Lock()
+			oc.inUse--
+			oc.lastActivity = time.Now()
+			t.lock.Unlock()
+		})
+	}
+}
+
+// removeExpired removes the observed connections which are idle and will have been closed by their transport.
+// The lock must be held.
+func (t *connTracker) removeExpired(now time.Time) {
+	for conn, oc := range t.observed {
+		if oc.inUse == 0 && now.Sub(oc.lastActivity) > oc.idleTimeout {
+			delete(t.observed, conn)
+		}
+	}
+}
+
+// Lookup returns the tracked connection for the connection handed to the http.Transport.
+func (t *connTracker) Lookup(conn net.Conn) (*trackedConn, bool) {
+	t.lock.Lock()
+	tc, ok := t.conns[conn]
+	t.lock.Unlock()
+
+	return tc, ok
+}
+
+// Snapshot returns the state of all open connections, ordered by when they were opened.
+func (t *connTracker) Snapshot() []ConnectionDiagnostics {
+	t.lock.Lock()
+	conns := make([]*trackedConn, 0, len(t.conns))
+
+	for _, tc := range t.conns {
+		conns = append(conns, tc)
+	}
+
+	t.removeExpired(time.Now())
+
+	snapshot := make([]ConnectionDiagnostics, 0, len(t.conns)+len(t.observed))
+
+	for _, oc := range t.observed {
+		snapshot = append(snapshot, oc.diagnostics())
+	}
+	t.lock.Unlock()
+
+	for _, tc := range conns {
+		snapshot = append(snapshot, tc.diagnostics())
+	}
+
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].ID < snapshot[j].ID
+	})
+
+	return snapshot
+}
+
+func (t *connTracker) remove(tc *trackedConn) {
+	t.lock.Lock()
+	delete(t.conns, tc.outer)
+	t.lock.Unlock()
+}
+
+// trackedConn records the activity on a connection.
+type trackedConn struct {
+	net.Conn
+
+	id           uint64
+	tracker      *connTracker
+	endpoint     string
+	connectedAt  time.Time
+	lastActivity atomic.Int64
+	inUse        atomic.Int32
+	closeOnce    sync.Once
+
+	// outer is the connection which wraps this one, such as the TLS client.
+	outer net.Conn
+}
+
+func (c *trackedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.lastActivity.Store(time.Now().UnixNano())
+	}
+
+	return n, err // nolint: wrapcheck
+}
+
+func (c *trackedConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if n > 0 {
+		c.lastActivity.Store(time.Now().UnixNano())
+	}
+
+	return n, err // nolint: wrapcheck
+}
+
+func (c *trackedConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.tracker.remove(c)
+	})
+
+	return c.Conn.Close() // nolint: wrapcheck
+}
+
+func (c *trackedConn) diagnostics() ConnectionDiagnostics {
+	state := ConnectionStateIdle
+	if c.inUse.Load() > 0 {
+		state = ConnectionStateActive
+	}
+
+	return ConnectionDiagnostics{
+		ID:            c.id,
+		Endpoint:      c.endpoint,
+		LocalAddress:  c.LocalAddr().String(),
+		RemoteAddress: c.RemoteAddr().String(),
+		State:         state,
+		ConnectedAt:   c.connectedAt,
+		LastActivity:  time.Unix(0, c.lastActivity.Load()),
+	}
+}
+
+// observedConn records the activity on a connection observed being used by a request. The lock of the tracker
+// guards every field.
+type observedConn struct {
+	id            uint64
+	endpoint      string
+	localAddress  string
+	remoteAddress string
+	connectedAt   time.Time
+	lastActivity  time.Time
+	inUse         int
+	idleTimeout   time.Duration
+}
+
+func (c *observedConn) diagnostics() ConnectionDiagnostics {
+	state := ConnectionStateIdle
+	if c.inUse > 0 {
+		state = ConnectionStateActive
+	}
+
+	return ConnectionDiagnostics{
+		ID:            c.id,
+		Endpoint:      c.endpoint,
+		LocalAddress:  c.localAddress,
+		RemoteAddress: c.remoteAddress,
+		State:         state,
+		ConnectedAt:   c.connectedAt,
+		LastActivity:  c.lastActivity,
+	}
+}
+
+// trackedBody marks the connection as no longer in use once the response body is closed.
+type trackedBody struct {
+	io.ReadCloser
+	conn      *trackedConn
+	closeOnce sync.Once
+}
+
+func (b *trackedBody) Close() error {
+	b.closeOnce.Do(func() {
+		b.conn.inUse.Add(-1)
+	})
+
+	return b.ReadCloser.Close() // nolint: wrapcheck
+}
@@ -0,0 +1,296 @@
+package cbcolumnar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/couchbase/gocbcore/v10"
+)
+
+// httpRowReader streams the rows out of an analytics response body which was received via httpClient.
+// The body can either be a full query response object, or a bare array of rows as returned when fetching
+// the results of a query handle.
+type httpRowReader struct {
+	body    io.ReadCloser
+	decoder *json.Decoder
+
+	statement  string
+	endpoint   string
+	statusCode int
+
+	bareRows  bool
+	inRows    bool
+	finished  bool
+	meta      map[string]json.RawMessage
+	metaBytes []byte
+	err       error
+	logger    *clusterLogger
+}
+
+// Endpoint returns the endpoint that the query was sent to.
+func (r *httpRowReader) Endpoint() string {
+	return r.endpoint
+}
+
+func newHTTPRowReader(body io.ReadCloser, statement, endpoint string, statusCode int, logger *clusterLogger) (*httpRowReader, error) {
+	r := &httpRowReader{
+		body:       body,
+		decoder:    json.NewDecoder(body),
+		statement:  statement,
+		endpoint:   endpoint,
+		statusCode: statusCode,
+		bareRows:   false,
+		inRows:     false,
+		finished:   false,
+		meta:       make(map[string]json.RawMessage),
+		metaBytes:  nil,
+		err:        nil,
+		logger:     logger,
+	}
+
+	tok, err := r.decoder.Token()
+	if err != nil {
+		r.closeBody()
+
+		return nil, fmt.Errorf("failed to read response body: %s", err) // nolint: err113, errorlint
+	}
+
+	switch tok {
+	case json.Delim('['):
+		r.bareRows = true
+		r.inRows = true
+	case json.Delim('{'):
+		r.readAttributes()
+	default:
+		r.closeBody()
+
+		return nil, fmt.Errorf("unexpected token in response body: %v", tok) // nolint: err113
+	}
+
+	if r.err != nil {
+		return nil, r.Err()
+	}
+
+	return r, nil
+}
+
+// readAttributes reads top level attributes until either the rows are reached, or the object ends.
+func (r *httpRowReader) readAttributes() {
+	for r.decoder.More() {
+		tok, err := r.decoder.Token()
+		if err != nil {
+			r.finishWithError(err)
+
+			return
+		}
+
+		key, ok := tok.(string)
+		if !ok {
+			r.finishWithError(fmt.Errorf("unexpected token in response body: %v", tok)) // nolint: err113
+
+			return
+		}
+
+		if key == "results" {
+			tok, err := r.decoder.Token()
+			if err != nil {
+				r.finishWithError(err)
+
+				return
+			}
+
+			if tok != json.Delim('[') {
+				r.finishWithError(fmt.Errorf("unexpected results token in response body: %v", tok)) // nolint: err113
+
+				return
+			}
+
+			r.inRows = true
+
+			return
+		}
+
+		var value json.RawMessage
+
+		err = r.decoder.Decode(&value)
+		if err != nil {
+			r.finishWithError(err)
+
+			return
+		}
+
+		r.meta[key] = value
+	}
+
+	_, err := r.decoder.Token()
+	if err != nil {
+		r.finishWithError(err)
+
+		return
+	}
+
+	r.finish()
+}
+
+func (r *httpRowReader) NextRow() []byte {
+	if r.finished || !r.inRows {
+		return nil
+	}
+
+	if r.decoder.More() {
+		var row json.RawMessage
+
+		err := r.decoder.Decode(&row)
+		if err != nil {
+			r.finishWithError(err)
+
+			return nil
+		}
+
+		return row
+	}
+
+	// Consume the closing bracket of the rows array.
+	_, err := r.decoder.Token()
+	if err != nil {
+		r.finishWithError(err)
+
+		return nil
+	}
+
+	r.inRows = false
+
+	if r.bareRows {
+		r.finish()
+
+		return nil
+	}
+
+	r.readAttributes()
+
+	return nil
+}
+
+func (r *httpRowReader) finish() {
+	metaBytes, err := json.Marshal(r.meta)
+	if err != nil {
+		r.finishWithError(err)
+
+		return
+	}
+
+	r.metaBytes = metaBytes
+	r.finished = true
+	r.closeBody()
+}
+
+func (r *httpRowReader) finishWithError(err error) {
+	r.err = err
+	r.finished = true
+	r.closeBody()
+}
+
+func (r *httpRowReader) closeBody() {
+	err := r.body.Close()
+	if err != nil {
+		r.logger.Debugf("Failed to close response body: %s", err)
+	}
+}
+
+func (r *httpRowReader) MetaData() (*QueryMetadata, error) {
+	if !r.finished {
+		return nil, errors.New("the result must be closed before accessing the meta-data") // nolint: err113
+	}
+
+	if r.metaBytes == nil {
+		return nil, errors.New("an error occurred during querying which has made the meta-data unavailable") // nolint: err113
+	}
+
+	return parseQueryMetadata(r.metaBytes, r.logger)
+}
+
+func (r *httpRowReader) Close() error {
+	if r.finished {
+		return nil
+	}
+
+	r.finished = true
+
+	err := r.body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close response body: %s", err) // nolint: err113, errorlint
+	}
+
+	return nil
+}
+
+func (r *httpRowReader) Err() error {
+	if r.err != nil {
+		return translateGocbcoreError(&gocbcore.ColumnarError{
+			InnerError:       r.err,
+			Statement:        r.statement,
+			Errors:           nil,
+			LastErrorCode:    0,
+			LastErrorMsg:     "",
+			Endpoint:         r.endpoint,
+			ErrorText:        "",
+			HTTPResponseCode: r.statusCode,
+			WasNotDispatched: false,
+		})
+	}
+
+	if r.metaBytes == nil {
+		return nil
+	}
+
+	return parseHTTPErrorResponse(r.metaBytes, r.statement, r.endpoint, r.statusCode)
+}
+
+type jsonAnalyticsError struct {
+	Code  uint32 `json:"code"`
+	Msg   string `json:"msg"`
+	Retry bool   `json:"retriable"`
+}
+
+type jsonAnalyticsErrorResponse struct {
+	Errors []jsonAnalyticsError `json:"errors"`
+}
+
+// parseHTTPErrorResponse parses any errors contained within an analytics response body, translating
+// them into the appropriate SDK error. If the body contains no errors, or is not a JSON object, then nil is returned.
+func parseHTTPErrorResponse(body []byte, statement, endpoint string, statusCode int) error {
+	var errResp jsonAnalyticsErrorResponse
+
+	err := json.Unmarshal(body, &errResp)
+	if err != nil {
+		// The body is not a JSON object and so cannot contain any errors.
+		return nil
+	}
+
+	if len(errResp.Errors) == 0 {
+		return nil
+	}
+
+	descs := make([]gocbcore.ColumnarErrorDesc, len(errResp.Errors))
+	for i, desc := range errResp.Errors {
+		descs[i] = gocbcore.ColumnarErrorDesc{
+			Code:    desc.Code,
+			Message: desc.Msg,
+			Retry:   desc.Retry,
+		}
+	}
+
+	return translateGocbcoreError(&gocbcore.ColumnarError{
+		InnerError:       ErrColumnar,
+		Statement:        statement,
+		Errors:           descs,
+		LastErrorCode:    0,
+		LastErrorMsg:     "",
+		Endpoint:         endpoint,
+		ErrorText:        string(body),
+		HTTPResponseCode: statusCode,
+		WasNotDispatched: false,
+	})
+}
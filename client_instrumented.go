@@ -0,0 +1,288 @@
+package cbcolumnar
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// instrumentedClusterClient wraps a clusterClient, creating a span, recording metrics and recording slow
+// queries to the threshold logger for each query executed via it.
+type instrumentedClusterClient struct {
+	clusterClient
+	tracer       RequestTracer
+	meter        Meter
+	thresholdLog *thresholdLogger
+	logger       *clusterLogger
+}
+
+// newInstrumentedClusterClient wraps the client, using a no-op implementation for the tracer or meter if nil.
+// If thresholdLog is nil then slow queries are not recorded, otherwise it is closed when the client is closed.
+func newInstrumentedClusterClient(client clusterClient, tracer RequestTracer, meter Meter,
+	thresholdLog *thresholdLogger, logger *clusterLogger) *instrumentedClusterClient {
+	if tracer == nil {
+		tracer = noopTracer{}
+	}
+
+	if meter == nil {
+		meter = noopMeter{}
+	}
+
+	return &instrumentedClusterClient{
+		clusterClient: client,
+		tracer:        tracer,
+		meter:         meter,
+		thresholdLog:  thresholdLog,
+		logger:        logger,
+	}
+}
+
+func (c *instrumentedClusterClient) Database(name string) databaseClient {
+	return &instrumentedDatabaseClient{
+		databaseClient: c.clusterClient.Database(name),
+		tracer:         c.tracer,
+		meter:          c.meter,
+		thresholdLog:   c.thresholdLog,
+		logger:         c.logger,
+	}
+}
+
+func (c *instrumentedClusterClient) QueryClient() queryClient {
+	return &instrumentedQueryClient{
+		queryClient:  c.clusterClient.QueryClient(),
+		tracer:       c.tracer,
+		metrics:      newQueryMetrics(c.meter, c.logger, nil),
+		thresholdLog: c.thresholdLog,
+		logger:       c.logger,
+		namespace:    nil,
+	}
+}
+
+func (c *instrumentedClusterClient) Close() error {
+	err := c.clusterClient.Close()
+
+	if c.thresholdLog != nil {
+		c.thresholdLog.Close()
+	}
+
+	return err
+}
+
+type instrumentedDatabaseClient struct {
+	databaseClient
+	tracer       RequestTracer
+	meter        Meter
+	thresholdLog *thresholdLogger
+	logger       *clusterLogger
+}
+
+func (c *instrumentedDatabaseClient) Scope(name string) scopeClient {
+	return &instrumentedScopeClient{
+		scopeClient:  c.databaseClient.Scope(name),
+		tracer:       c.tracer,
+		meter:        c.meter,
+		thresholdLog: c.thresholdLog,
+		logger:       c.logger,
+		databaseName: c.Name(),
+	}
+}
+
+type instrumentedScopeClient struct {
+	scopeClient
+	tracer       RequestTracer
+	meter        Meter
+	thresholdLog *thresholdLogger
+	logger       *clusterLogger
+	databaseName string
+}
+
+func (c *instrumentedScopeClient) QueryClient() queryClient {
+	namespace := &queryClientNamespace{
+		Database: c.databaseName,
+		Scope:    c.Name(),
+	}
+
+	return &instrumentedQueryClient{
+		queryClient:  c.scopeClient.QueryClient(),
+		tracer:       c.tracer,
+		metrics:      newQueryMetrics(c.meter, c.logger, namespace),
+		thresholdLog: c.thresholdLog,
+		logger:       c.logger,
+		namespace:    namespace,
+	}
+}
+
+// instrumentedQueryClient creates a span, records metrics and records slow queries to the threshold logger for
+// each query executed via Query, which are completed once the rows have been read or the result is closed.
+// Queries started via StartQuery are not instrumented.
+type instrumentedQueryClient struct {
+	queryClient
+	tracer       RequestTracer
+	metrics      *queryMetrics
+	thresholdLog *thresholdLogger
+	logger       *clusterLogger
+	namespace    *queryClientNamespace
+}
+
+func (c *instrumentedQueryClient) Query(ctx context.Context, statement string, opts *QueryOptions) (*QueryResult, error) {
+	// The client context ID is generated here, rather than when the request is created, so that it can be
+	// recorded on the span.
+	if opts.ClientContextID == "" {
+		opts.ClientContextID = uuid.NewString()
+	}
+
+	start := time.Now()
+
+	ctx, span := c.tracer.RequestSpan(ctx, spanNameQuery)
+	span.SetAttribute(spanAttribDBSystem, spanDBSystemCouchbase)
+	span.SetAttribute(spanAttribService, spanServiceAnalytics)
+	span.SetAttribute(spanAttribClientContextID, opts.ClientContextID)
+
+	if traced, ok := traceStatement(statement, c.logger.RedactionLevel()); ok {
+		span.SetAttribute(spanAttribStatement, traced)
+	}
+
+	if c.namespace != nil {
+		span.SetAttribute(spanAttribDatabase, c.namespace.Database)
+		span.SetAttribute(spanAttribScope, c.namespace.Scope)
+	}
+
+	res, err := c.queryClient.Query(ctx, statement, opts)
+	if err != nil {
+		duration := time.Since(start)
+
+		c.metrics.RecordDuration(meterNameQueryDuration, duration)
+		c.metrics.RecordError(err)
+
+		if c.thresholdLog != nil {
+			c.thresholdLog.Record(thresholdLogItem{
+				Statement:       statement,
+				ClientContextID: opts.ClientContextID,
+				Endpoint:        "",
+				TotalDuration:   duration,
+				ServerDuration:  0,
+			})
+		}
+
+		span.RecordError(err)
+		span.End()
+
+		return nil, err
+	}
+
+	res.reader = &instrumentedRowReader{
+		reader:          res.reader,
+		span:            span,
+		metrics:         c.metrics,
+		thresholdLog:    c.thresholdLog,
+		statement:       statement,
+		clientContextID: opts.ClientContextID,
+		start:           start,
+		rowCount:        0,
+		byteCount:       0,
+		completed:       false,
+	}
+
+	return res, nil
+}
+
+// endpointRowReader is implemented by row readers which know the endpoint that the query was sent to.
+type endpointRowReader interface {
+	Endpoint() string
+}
+
+type instrumentedRowReader struct {
+	reader          analyticsRowReader
+	span            RequestSpan
+	metrics         *queryMetrics
+	thresholdLog    *thresholdLogger
+	statement       string
+	clientContextID string
+	start           time.Time
+	rowCount        uint64
+	byteCount       uint64
+	completed       bool
+}
+
+func (r *instrumentedRowReader) NextRow() []byte {
+	row := r.reader.NextRow()
+	if row == nil {
+		r.complete(true)
+
+		return nil
+	}
+
+	r.rowCount++
+	r.byteCount += uint64(len(row))
+
+	return row
+}
+
+func (r *instrumentedRowReader) MetaData() (*QueryMetadata, error) {
+	return r.reader.MetaData()
+}
+
+func (r *instrumentedRowReader) Close() error {
+	err := r.reader.Close()
+	r.complete(false)
+
+	return err
+}
+
+func (r *instrumentedRowReader) Err() error {
+	return r.reader.Err()
+}
+
+// complete ends the span, records the metrics and records the query to the threshold logger once the stream has
+// finished or been closed. Errors and the server elapsed and execution times are only available when the stream
+// has finished.
+func (r *instrumentedRowReader) complete(finished bool) {
+	if r.completed {
+		return
+	}
+
+	r.completed = true
+
+	duration := time.Since(r.start)
+
+	var serverDuration time.Duration
+
+	r.metrics.RecordDuration(meterNameQueryDuration, duration)
+	r.metrics.Increment(meterNameQueryRows, r.rowCount)
+	r.metrics.Increment(meterNameQueryBytes, r.byteCount)
+
+	r.span.SetAttribute(spanAttribRowCount, r.rowCount)
+
+	if finished {
+		err := r.reader.Err()
+		if err != nil {
+			r.metrics.RecordError(err)
+			r.span.RecordError(err)
+		} else if meta, err := r.reader.MetaData(); err == nil {
+			r.metrics.RecordDuration(meterNameQueryServerElapsedTime, meta.Metrics.ElapsedTime)
+			r.metrics.RecordDuration(meterNameQueryServerExecutionTime, meta.Metrics.ExecutionTime)
+
+			r.span.SetAttribute(spanAttribServerDuration, meta.Metrics.ElapsedTime)
+
+			serverDuration = meta.Metrics.ElapsedTime
+		}
+	}
+
+	r.span.End()
+
+	if r.thresholdLog != nil {
+		var endpoint string
+		if reader, ok := r.reader.(endpointRowReader); ok {
+			endpoint = reader.Endpoint()
+		}
+
+		r.thresholdLog.Record(thresholdLogItem{
+			Statement:       r.statement,
+			ClientContextID: r.clientContextID,
+			Endpoint:        endpoint,
+			TotalDuration:   duration,
+			ServerDuration:  serverDuration,
+		})
+	}
+}
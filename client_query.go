@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -15,23 +16,31 @@ import (
 
 type queryClient interface {
 	Query(ctx context.Context, statement string, opts *QueryOptions) (*QueryResult, error)
+	StartQuery(ctx context.Context, statement string, opts *QueryOptions) (*QueryHandle, error)
 }
 
-type gocbcoreQueryClientNamespace struct {
+type queryClientNamespace struct {
 	Database string
 	Scope    string
 }
+
 type gocbcoreQueryClient struct {
-	agent               *gocbcore.ColumnarAgent
+	agent               *agentClient
+	handleClient        queryHandleClient
+	credentials         CredentialProvider
+	logger              *clusterLogger
 	defaultQueryTimeout time.Duration
 	defaultUnmarshaler  Unmarshaler
-	namespace           *gocbcoreQueryClientNamespace
+	namespace           *queryClientNamespace
 }
 
-func newGocbcoreQueryClient(agent *gocbcore.ColumnarAgent, defaultQueryTimeout time.Duration,
-	defaultUnmarshaler Unmarshaler, namespace *gocbcoreQueryClientNamespace) *gocbcoreQueryClient {
+func newGocbcoreQueryClient(agent *agentClient, handleClient queryHandleClient, credentials CredentialProvider,
+	logger *clusterLogger, defaultQueryTimeout time.Duration, defaultUnmarshaler Unmarshaler, namespace *queryClientNamespace) *gocbcoreQueryClient {
 	return &gocbcoreQueryClient{
 		agent:               agent,
+		handleClient:        handleClient,
+		credentials:         credentials,
+		logger:              logger,
 		defaultQueryTimeout: defaultQueryTimeout,
 		defaultUnmarshaler:  defaultUnmarshaler,
 		namespace:           namespace,
@@ -39,20 +48,37 @@ func newGocbcoreQueryClient(agent *gocbcore.ColumnarAgent, defaultQueryTimeout t
 }
 
 func (c *gocbcoreQueryClient) Query(ctx context.Context, statement string, opts *QueryOptions) (*QueryResult, error) {
-	coreOpts, err := c.translateQueryOptions(ctx, statement, opts)
+	coreOpts, err := translateQueryOptions(ctx, statement, opts, c.defaultQueryTimeout, c.namespace)
 	if err != nil {
 		return nil, err
 	}
 
-	if c.namespace != nil {
-		coreOpts.Payload["query_context"] = fmt.Sprintf("default:`%s`.`%s`", c.namespace.Database, c.namespace.Scope)
-	}
+	c.logger.DebugAttrs("Dispatching query", slog.Any(logAttrClientContextID, coreOpts.Payload["client_context_id"]))
 
-	coreOpts.Payload["client_context_id"] = uuid.NewString()
+	res, err := retryOnInvalidCredential(ctx, c.credentials, c.logger, func() (analyticsRowReader, error) {
+		credential, err := fetchCredential(ctx, c.credentials)
+		if err != nil {
+			return nil, err
+		}
+
+		if credential.Certificate != nil {
+			reader, err := c.agent.QueryViaHTTP(ctx, *coreOpts)
+			if err != nil {
+				return nil, err
+			}
+
+			return reader, nil
+		}
+
+		res, err := c.agent.Query(ctx, *coreOpts)
+		if err != nil {
+			return nil, translateGocbcoreError(err)
+		}
 
-	res, err := c.agent.Query(ctx, *coreOpts)
+		return c.newRowReader(res), nil
+	})
 	if err != nil {
-		return nil, translateGocbcoreError(err)
+		return nil, err
 	}
 
 	unmarshaler := opts.Unmarshaler
@@ -61,12 +87,28 @@ func (c *gocbcoreQueryClient) Query(ctx context.Context, statement string, opts
 	}
 
 	return &QueryResult{
-		reader:      c.newRowReader(res),
+		reader:      res,
 		unmarshaler: unmarshaler,
+		logger:      c.logger,
+		finished:    false,
+		closed:      false,
 	}, nil
 }
 
-func (c *gocbcoreQueryClient) translateQueryOptions(ctx context.Context, statement string, opts *QueryOptions) (*gocbcore.ColumnarQueryOptions, error) {
+func (c *gocbcoreQueryClient) StartQuery(ctx context.Context, statement string, opts *QueryOptions) (*QueryHandle, error) {
+	coreOpts, err := translateQueryOptions(ctx, statement, opts, c.defaultQueryTimeout, c.namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// The context only applies to submitting the query, so we always use the default server timeout.
+	coreOpts.Payload["timeout"] = c.defaultQueryTimeout.String()
+
+	return c.handleClient.StartQuery(ctx, coreOpts, opts.Unmarshaler)
+}
+
+func translateQueryOptions(ctx context.Context, statement string, opts *QueryOptions, defaultQueryTimeout time.Duration,
+	namespace *queryClientNamespace) (*gocbcore.ColumnarQueryOptions, error) {
 	var priority *int
 
 	if opts.Priority != nil && *opts.Priority {
@@ -113,15 +155,42 @@ func (c *gocbcoreQueryClient) translateQueryOptions(ctx context.Context, stateme
 		execOpts["readonly"] = *opts.ReadOnly
 	}
 
+	if opts.Profile != nil {
+		switch *opts.Profile {
+		case QueryProfileModeOff:
+			execOpts["profile"] = "off"
+		case QueryProfileModeCounts:
+			execOpts["profile"] = "counts"
+		case QueryProfileModeTimings:
+			execOpts["profile"] = "timings"
+		default:
+			return nil, invalidArgumentError{
+				ArgumentName: "Profile",
+				Reason:       "unknown value",
+			}
+		}
+	}
+
 	deadline, ok := ctx.Deadline()
 	if ok {
 		execOpts["timeout"] = (time.Until(deadline) + 5*time.Second).String()
 	} else {
-		execOpts["timeout"] = c.defaultQueryTimeout.String()
+		execOpts["timeout"] = defaultQueryTimeout.String()
 	}
 
 	execOpts["statement"] = statement
 
+	if namespace != nil {
+		execOpts["query_context"] = fmt.Sprintf("default:`%s`.`%s`", namespace.Database, namespace.Scope)
+	}
+
+	clientContextID := opts.ClientContextID
+	if clientContextID == "" {
+		clientContextID = uuid.NewString()
+	}
+
+	execOpts["client_context_id"] = clientContextID
+
 	return &gocbcore.ColumnarQueryOptions{
 		Payload:      execOpts,
 		Priority:     priority,
@@ -131,15 +200,22 @@ func (c *gocbcoreQueryClient) translateQueryOptions(ctx context.Context, stateme
 }
 
 type gocbcoreRowReader struct {
-	reader *gocbcore.ColumnarRowReader
+	reader *agentRows
+	logger *clusterLogger
 }
 
-func (c *gocbcoreQueryClient) newRowReader(result *gocbcore.ColumnarRowReader) *gocbcoreRowReader {
+func (c *gocbcoreQueryClient) newRowReader(result *agentRows) *gocbcoreRowReader {
 	return &gocbcoreRowReader{
 		reader: result,
+		logger: c.logger,
 	}
 }
 
+// Endpoint returns the endpoint that the agent sent the query to.
+func (c *gocbcoreRowReader) Endpoint() string {
+	return c.reader.endpoint
+}
+
 func (c *gocbcoreRowReader) NextRow() []byte {
 	return c.reader.NextRow()
 }
@@ -150,25 +226,40 @@ func (c *gocbcoreRowReader) MetaData() (*QueryMetadata, error) {
 		return nil, translateGocbcoreError(err)
 	}
 
+	return parseQueryMetadata(metaBytes, c.logger)
+}
+
+func parseQueryMetadata(metaBytes []byte, logger *clusterLogger) (*QueryMetadata, error) {
 	var jsonResp jsonAnalyticsResponse
 
-	err = json.Unmarshal(metaBytes, &jsonResp)
+	err := json.Unmarshal(metaBytes, &jsonResp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal metadata: %s", err) // nolint: err113, errorlint
 	}
 
 	meta := &QueryMetadata{
-		RequestID: "",
+		RequestID:       "",
+		ClientContextID: "",
 		Metrics: QueryMetrics{
 			ElapsedTime:      0,
 			ExecutionTime:    0,
 			ResultCount:      0,
 			ResultSize:       0,
 			ProcessedObjects: 0,
+			MutationCount:    0,
+			SortCount:        0,
+			ErrorCount:       0,
+			WarningCount:     0,
 		},
-		Warnings: nil,
+		Warnings:  nil,
+		Profile:   nil,
+		Status:    "",
+		Signature: nil,
+		Raw:       nil,
+		Truncated: false,
 	}
-	meta.fromData(jsonResp)
+	meta.fromData(jsonResp, logger)
+	meta.Raw = metaBytes
 
 	return meta, nil
 }
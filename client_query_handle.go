@@ -0,0 +1,379 @@
+package cbcolumnar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/couchbase/gocbcore/v10"
+)
+
+type queryHandleClient interface {
+	StartQuery(ctx context.Context, opts *gocbcore.ColumnarQueryOptions, unmarshaler Unmarshaler) (*QueryHandle, error)
+	Status(ctx context.Context, handle *QueryHandle) (*QueryHandleStatus, error)
+	FetchResults(ctx context.Context, handle *QueryHandle) (*QueryResult, error)
+	Discard(ctx context.Context, handle *QueryHandle) error
+	CancelQuery(ctx context.Context, clientContextID string) error
+}
+
+var (
+	errMissingQueryHandle = errors.New("server did not return a query handle")
+	errInvalidQueryHandle = errors.New("server returned an invalid query handle")
+)
+
+type httpQueryHandleClient struct {
+	http               *httpClient
+	discoverer         endpointDiscoverer
+	defaultUnmarshaler Unmarshaler
+}
+
+// newHTTPQueryHandleClient creates a client which manages query handles via http. If discoverer is nil then the
+// endpoints known to http are used as they are, otherwise they are discovered from the cluster as required.
+func newHTTPQueryHandleClient(http *httpClient, discoverer endpointDiscoverer,
+	defaultUnmarshaler Unmarshaler) *httpQueryHandleClient {
+	return &httpQueryHandleClient{
+		http:               http,
+		discoverer:         discoverer,
+		defaultUnmarshaler: defaultUnmarshaler,
+	}
+}
+
+// endpoints returns the analytics endpoints, first discovering them if they were not discovered recently.
+func (c *httpQueryHandleClient) endpoints(ctx context.Context) ([]string, error) {
+	if c.discoverer != nil {
+		err := c.discoverer.DiscoverEndpoints(ctx)
+		if err != nil {
+			return nil, newHTTPError(err, "", "", 0)
+		}
+	}
+
+	endpoints := c.http.Endpoints()
+	if len(endpoints) == 0 {
+		return nil, newHTTPError(errNoEndpoints, "", "", 0)
+	}
+
+	return endpoints, nil
+}
+
+func (c *httpQueryHandleClient) StartQuery(ctx context.Context, opts *gocbcore.ColumnarQueryOptions,
+	unmarshaler Unmarshaler) (*QueryHandle, error) {
+	statement, _ := opts.Payload["statement"].(string)
+
+	opts.Payload["mode"] = "async"
+
+	body, err := json.Marshal(opts.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query payload: %s", err) // nolint: err113, errorlint
+	}
+
+	endpoints, err := c.endpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := endpoints[rand.Intn(len(endpoints))] // #nosec G404
+
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+
+	if opts.Priority != nil {
+		header.Set("Analytics-Priority", fmt.Sprintf("%d", *opts.Priority))
+	}
+
+	respBody, statusCode, err := c.doRequest(ctx, http.MethodPost, endpoint, "/api/v1/request", header, body, statement)
+	if err != nil {
+		return nil, err
+	}
+
+	var jsonResp jsonAnalyticsResponse
+
+	err = json.Unmarshal(respBody, &jsonResp)
+	if err != nil {
+		return nil, newHTTPError(fmt.Errorf("failed to parse response: %s", err), statement, endpoint, statusCode) // nolint: err113, errorlint
+	}
+
+	if jsonResp.Handle == "" {
+		return nil, newHTTPError(errMissingQueryHandle, statement, endpoint, statusCode)
+	}
+
+	if validateQueryHandlePath(jsonResp.Handle, queryHandleStatusPathPrefix) != nil {
+		return nil, newHTTPError(errInvalidQueryHandle, statement, endpoint, statusCode)
+	}
+
+	return &QueryHandle{
+		client:       c,
+		requestID:    jsonResp.RequestID,
+		endpoint:     endpoint,
+		statusHandle: jsonResp.Handle,
+		statement:    statement,
+		lock:         sync.Mutex{},
+		resultHandle: "",
+		unmarshaler:  unmarshaler,
+	}, nil
+}
+
+// checkEndpoint verifies that the endpoint of the handle is one of the analytics endpoints of the cluster, so
+// that the credential is never sent to an endpoint taken from a handle which was loaded from elsewhere.
+func (c *httpQueryHandleClient) checkEndpoint(ctx context.Context, handle *QueryHandle) error {
+	if slices.Contains(c.http.Endpoints(), handle.endpoint) {
+		return nil
+	}
+
+	// The handle may have been started by another process, before the endpoint was known to this one.
+	if c.discoverer != nil {
+		err := c.discoverer.DiscoverEndpoints(ctx)
+		if err != nil {
+			return newHTTPError(err, handle.statement, "", 0)
+		}
+
+		if slices.Contains(c.http.Endpoints(), handle.endpoint) {
+			return nil
+		}
+	}
+
+	return invalidArgumentError{
+		ArgumentName: "QueryHandle",
+		Reason:       fmt.Sprintf("endpoint %s is not an analytics endpoint of the cluster", handle.endpoint),
+	}
+}
+
+func (c *httpQueryHandleClient) Status(ctx context.Context, handle *QueryHandle) (*QueryHandleStatus, error) {
+	err := c.checkEndpoint(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, _, err := c.doRequest(ctx, http.MethodGet, handle.endpoint, handle.statusHandle, nil, nil, handle.statement)
+	if err != nil {
+		return nil, err
+	}
+
+	var jsonStatus jsonAnalyticsHandleStatus
+
+	err = json.Unmarshal(respBody, &jsonStatus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query status: %s", err) // nolint: err113, errorlint
+	}
+
+	if jsonStatus.Handle != "" {
+		if validateQueryHandlePath(jsonStatus.Handle, queryHandleResultPathPrefix) != nil {
+			return nil, newHTTPError(errInvalidQueryHandle, handle.statement, handle.endpoint, 200)
+		}
+
+		handle.storeResultHandle(jsonStatus.Handle)
+	}
+
+	status := &QueryHandleStatus{
+		Status:    "",
+		RequestID: "",
+		Metrics: QueryMetrics{
+			ElapsedTime:      0,
+			ExecutionTime:    0,
+			ResultCount:      0,
+			ResultSize:       0,
+			ProcessedObjects: 0,
+			MutationCount:    0,
+			SortCount:        0,
+			ErrorCount:       0,
+			WarningCount:     0,
+		},
+	}
+	status.fromData(jsonStatus, c.http.logger)
+
+	if status.RequestID == "" {
+		status.RequestID = handle.requestID
+	}
+
+	return status, nil
+}
+
+func (c *httpQueryHandleClient) FetchResults(ctx context.Context, handle *QueryHandle) (*QueryResult, error) {
+	err := c.checkEndpoint(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.waitForCompletion(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(ctx, http.MethodGet, handle.endpoint, handle.loadResultHandle(), nil, nil)
+	if err != nil {
+		return nil, newHTTPError(err, handle.statement, handle.endpoint, 0)
+	}
+
+	if resp.StatusCode != 200 {
+		respBody, err := c.http.readBody(resp, handle.statement, handle.endpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, newHTTPResponseError(respBody, handle.statement, handle.endpoint, resp.StatusCode)
+	}
+
+	reader, err := newHTTPRowReader(resp.Body, handle.statement, handle.endpoint, resp.StatusCode, c.http.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	unmarshaler := handle.unmarshaler
+	if unmarshaler == nil {
+		unmarshaler = c.defaultUnmarshaler
+	}
+
+	return &QueryResult{
+		reader:      reader,
+		unmarshaler: unmarshaler,
+		logger:      c.http.logger,
+		finished:    false,
+		closed:      false,
+	}, nil
+}
+
+func (c *httpQueryHandleClient) Discard(ctx context.Context, handle *QueryHandle) error {
+	err := c.checkEndpoint(ctx, handle)
+	if err != nil {
+		return err
+	}
+
+	resultHandle := handle.loadResultHandle()
+	if resultHandle == "" {
+		status, err := c.Status(ctx, handle)
+		if err != nil {
+			return err
+		}
+
+		resultHandle = handle.loadResultHandle()
+		if resultHandle == "" {
+			return invalidArgumentError{
+				ArgumentName: "QueryHandle",
+				Reason:       fmt.Sprintf("query has not completed, current status is %s", status.Status),
+			}
+		}
+	}
+
+	_, _, err = c.doRequest(ctx, http.MethodDelete, handle.endpoint, resultHandle, nil, nil, handle.statement)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CancelQuery sends the cancellation request to every known endpoint, as we cannot know which
+// node is executing the query.
+func (c *httpQueryHandleClient) CancelQuery(ctx context.Context, clientContextID string) error {
+	endpoints, err := c.endpoints(ctx)
+	if err != nil {
+		return err
+	}
+
+	path := "/api/v1/active_requests?client_context_id=" + url.QueryEscape(clientContextID)
+
+	var lastErr error
+
+	for _, endpoint := range endpoints {
+		resp, err := c.http.Do(ctx, http.MethodDelete, endpoint, path, nil, nil)
+		if err != nil {
+			lastErr = newHTTPError(err, "", endpoint, 0)
+
+			continue
+		}
+
+		respBody, err := c.http.readBody(resp, "", endpoint)
+		if err != nil {
+			lastErr = err
+
+			continue
+		}
+
+		if resp.StatusCode == 200 {
+			return nil
+		}
+
+		if resp.StatusCode == 404 {
+			lastErr = newColumnarError("", endpoint, resp.StatusCode).
+				withMessage("no active query found with the given client context ID")
+
+			continue
+		}
+
+		lastErr = newHTTPResponseError(respBody, "", endpoint, resp.StatusCode)
+	}
+
+	return lastErr
+}
+
+func (c *httpQueryHandleClient) waitForCompletion(ctx context.Context, handle *QueryHandle) error {
+	backoff := 100 * time.Millisecond
+
+	for handle.loadResultHandle() == "" {
+		status, err := c.Status(ctx, handle)
+		if err != nil {
+			return err
+		}
+
+		switch status.Status {
+		case QueryStatusQueued, QueryStatusRunning:
+		case QueryStatusSuccess:
+			if handle.loadResultHandle() == "" {
+				return newHTTPError(errMissingQueryHandle, handle.statement, handle.endpoint, 200)
+			}
+
+			return nil
+		case QueryStatusTimeout:
+			return newColumnarError(handle.statement, handle.endpoint, 200).
+				withMessage("query timed out on the server").
+				withCause(ErrTimeout)
+		default:
+			return newColumnarError(handle.statement, handle.endpoint, 200).
+				withMessage(fmt.Sprintf("query completed with status %s", status.Status))
+		}
+
+		select {
+		case <-ctx.Done():
+			return newHTTPError(ctx.Err(), handle.statement, handle.endpoint, 0)
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > 1*time.Second {
+			backoff = 1 * time.Second
+		}
+	}
+
+	return nil
+}
+
+// doRequest sends a request to the server and reads the full response body, translating any errors.
+func (c *httpQueryHandleClient) doRequest(ctx context.Context, method, endpoint, path string, header http.Header,
+	body []byte, statement string) ([]byte, int, error) {
+	resp, err := c.http.Do(ctx, method, endpoint, path, header, body)
+	if err != nil {
+		return nil, 0, newHTTPError(err, statement, endpoint, 0)
+	}
+
+	respBody, err := c.http.readBody(resp, statement, endpoint)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, 0, newHTTPResponseError(respBody, statement, endpoint, resp.StatusCode)
+	}
+
+	err = parseHTTPErrorResponse(respBody, statement, endpoint, resp.StatusCode)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return respBody, resp.StatusCode, nil
+}
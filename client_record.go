@@ -0,0 +1,375 @@
+package cbcolumnar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+type jsonRecording struct {
+	Exchanges []*jsonRecordedExchange `json:"exchanges"`
+}
+
+type jsonRecordedExchange struct {
+	// Request contains the query payload, excluding any fields which change between executions.
+	Request     json.RawMessage    `json:"request"`
+	Rows        []json.RawMessage  `json:"rows,omitempty"`
+	MetaData    json.RawMessage    `json:"metadata,omitempty"`
+	Error       *jsonRecordedError `json:"error,omitempty"`
+	StreamError *jsonRecordedError `json:"streamError,omitempty"`
+}
+
+type jsonRecordedErrorDesc struct {
+	Code    uint32 `json:"code"`
+	Message string `json:"msg"`
+}
+
+type jsonRecordedError struct {
+	Cause      string                  `json:"cause,omitempty"`
+	Message    string                  `json:"message,omitempty"`
+	Code       int                     `json:"code,omitempty"`
+	Errors     []jsonRecordedErrorDesc `json:"errors,omitempty"`
+	Statement  string                  `json:"statement,omitempty"`
+	Endpoint   string                  `json:"endpoint,omitempty"`
+	StatusCode int                     `json:"statusCode,omitempty"`
+}
+
+var recordedErrorCauses = map[string]error{
+	"timeout":            ErrTimeout,
+	"invalid_credential": ErrInvalidCredential,
+	"query":              ErrQuery,
+	"canceled":           context.Canceled,
+	"deadline_exceeded":  context.DeadlineExceeded,
+}
+
+func newRecordedError(err error) *jsonRecordedError {
+	recorded := &jsonRecordedError{
+		Cause:      "",
+		Message:    "",
+		Code:       0,
+		Errors:     nil,
+		Statement:  "",
+		Endpoint:   "",
+		StatusCode: 0,
+	}
+
+	for name, cause := range recordedErrorCauses {
+		if errors.Is(err, cause) {
+			recorded.Cause = name
+
+			break
+		}
+	}
+
+	var columnarErr *ColumnarError
+	if !errors.As(err, &columnarErr) {
+		recorded.Message = err.Error()
+
+		return recorded
+	}
+
+	var queryErr *QueryError
+	if errors.As(err, &queryErr) {
+		recorded.Code = queryErr.code
+		recorded.Message = queryErr.message
+	} else {
+		recorded.Message = columnarErr.message
+	}
+
+	for _, desc := range columnarErr.errors {
+		recorded.Errors = append(recorded.Errors, jsonRecordedErrorDesc(desc))
+	}
+
+	recorded.Statement = columnarErr.statement
+	recorded.Endpoint = columnarErr.endpoint
+	recorded.StatusCode = columnarErr.httpResponseCode
+
+	return recorded
+}
+
+func (e *jsonRecordedError) toError() error {
+	descs := make([]columnarErrorDesc, len(e.Errors))
+	for i, desc := range e.Errors {
+		descs[i] = columnarErrorDesc(desc)
+	}
+
+	if e.Code != 0 {
+		queryErr := newQueryError(e.Statement, e.Endpoint, e.StatusCode, e.Code, e.Message).withErrors(descs)
+		if cause, ok := recordedErrorCauses[e.Cause]; ok {
+			queryErr.cause.cause = cause
+		}
+
+		return queryErr
+	}
+
+	baseErr := newColumnarError(e.Statement, e.Endpoint, e.StatusCode).withErrors(descs)
+	baseErr.message = e.Message
+	baseErr.cause = recordedErrorCauses[e.Cause]
+
+	return baseErr
+}
+
+// newRecordedRequest creates the representation of a query request which is used to match requests when
+// replaying. The client context ID and timeout are excluded as they change between executions.
+func newRecordedRequest(ctx context.Context, statement string, opts *QueryOptions,
+	namespace *queryClientNamespace) (json.RawMessage, error) {
+	coreOpts, err := translateQueryOptions(ctx, statement, opts, 0, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(coreOpts.Payload, "client_context_id")
+	delete(coreOpts.Payload, "timeout")
+
+	return canonicalJSON(coreOpts.Payload)
+}
+
+// canonicalJSON marshals the value such that equal values always produce identical JSON, regardless
+// of the types used to represent them.
+func canonicalJSON(value interface{}) (json.RawMessage, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal recorded request: %s", err) // nolint: err113, errorlint
+	}
+
+	var generic interface{}
+
+	err = json.Unmarshal(data, &generic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal recorded request: %s", err) // nolint: err113, errorlint
+	}
+
+	data, err = json.Marshal(generic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal recorded request: %s", err) // nolint: err113, errorlint
+	}
+
+	return data, nil
+}
+
+// queryRecorder collects the query exchanges made by a cluster, and writes them to a golden file.
+type queryRecorder struct {
+	path string
+
+	lock      sync.Mutex
+	exchanges []*jsonRecordedExchange
+}
+
+func newQueryRecorder(path string) *queryRecorder {
+	return &queryRecorder{
+		path:      path,
+		lock:      sync.Mutex{},
+		exchanges: nil,
+	}
+}
+
+func (r *queryRecorder) Add(exchange *jsonRecordedExchange) {
+	r.lock.Lock()
+	r.exchanges = append(r.exchanges, exchange)
+	r.lock.Unlock()
+}
+
+func (r *queryRecorder) Update(fn func()) {
+	r.lock.Lock()
+	fn()
+	r.lock.Unlock()
+}
+
+func (r *queryRecorder) Save() error {
+	r.lock.Lock()
+	data, err := json.MarshalIndent(jsonRecording{
+		Exchanges: r.exchanges,
+	}, "", "  ")
+	r.lock.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal recorded queries: %s", err) // nolint: err113, errorlint
+	}
+
+	err = os.WriteFile(r.path, data, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write recorded queries: %w", err)
+	}
+
+	return nil
+}
+
+// recordingClusterClient wraps a clusterClient, recording all queries executed via it and writing them
+// to a golden file when the client is closed.
+type recordingClusterClient struct {
+	clusterClient
+	recorder *queryRecorder
+}
+
+func newRecordingClusterClient(client clusterClient, path string) *recordingClusterClient {
+	return &recordingClusterClient{
+		clusterClient: client,
+		recorder:      newQueryRecorder(path),
+	}
+}
+
+func (c *recordingClusterClient) Database(name string) databaseClient {
+	return &recordingDatabaseClient{
+		databaseClient: c.clusterClient.Database(name),
+		recorder:       c.recorder,
+	}
+}
+
+func (c *recordingClusterClient) QueryClient() queryClient {
+	return &recordingQueryClient{
+		queryClient: c.clusterClient.QueryClient(),
+		recorder:    c.recorder,
+		namespace:   nil,
+	}
+}
+
+func (c *recordingClusterClient) Close() error {
+	err := c.clusterClient.Close()
+
+	saveErr := c.recorder.Save()
+	if saveErr != nil {
+		return saveErr
+	}
+
+	return err
+}
+
+type recordingDatabaseClient struct {
+	databaseClient
+	recorder *queryRecorder
+}
+
+func (c *recordingDatabaseClient) Scope(name string) scopeClient {
+	return &recordingScopeClient{
+		scopeClient:  c.databaseClient.Scope(name),
+		recorder:     c.recorder,
+		databaseName: c.Name(),
+	}
+}
+
+type recordingScopeClient struct {
+	scopeClient
+	recorder     *queryRecorder
+	databaseName string
+}
+
+func (c *recordingScopeClient) QueryClient() queryClient {
+	return &recordingQueryClient{
+		queryClient: c.scopeClient.QueryClient(),
+		recorder:    c.recorder,
+		namespace: &queryClientNamespace{
+			Database: c.databaseName,
+			Scope:    c.Name(),
+		},
+	}
+}
+
+// recordingQueryClient records queries executed via Query. Queries started via StartQuery are not recorded.
+type recordingQueryClient struct {
+	queryClient
+	recorder  *queryRecorder
+	namespace *queryClientNamespace
+}
+
+func (c *recordingQueryClient) Query(ctx context.Context, statement string, opts *QueryOptions) (*QueryResult, error) {
+	request, err := newRecordedRequest(ctx, statement, opts, c.namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	exchange := &jsonRecordedExchange{
+		Request:     request,
+		Rows:        nil,
+		MetaData:    nil,
+		Error:       nil,
+		StreamError: nil,
+	}
+	c.recorder.Add(exchange)
+
+	res, err := c.queryClient.Query(ctx, statement, opts)
+	if err != nil {
+		c.recorder.Update(func() {
+			exchange.Error = newRecordedError(err)
+		})
+
+		return nil, err
+	}
+
+	res.reader = &recordingRowReader{
+		reader:    res.reader,
+		recorder:  c.recorder,
+		exchange:  exchange,
+		rows:      nil,
+		completed: false,
+	}
+
+	return res, nil
+}
+
+type recordingRowReader struct {
+	reader    analyticsRowReader
+	recorder  *queryRecorder
+	exchange  *jsonRecordedExchange
+	rows      []json.RawMessage
+	completed bool
+}
+
+func (r *recordingRowReader) NextRow() []byte {
+	row := r.reader.NextRow()
+	if row == nil {
+		r.complete()
+
+		return nil
+	}
+
+	r.rows = append(r.rows, append(json.RawMessage(nil), row...))
+
+	return row
+}
+
+func (r *recordingRowReader) MetaData() (*QueryMetadata, error) {
+	return r.reader.MetaData()
+}
+
+func (r *recordingRowReader) Close() error {
+	err := r.reader.Close()
+	r.complete()
+
+	return err
+}
+
+func (r *recordingRowReader) Err() error {
+	return r.reader.Err()
+}
+
+// complete records the outcome of the query once the stream has finished or been closed.
+func (r *recordingRowReader) complete() {
+	if r.completed {
+		return
+	}
+
+	r.completed = true
+
+	var metaData json.RawMessage
+
+	meta, err := r.reader.MetaData()
+	if err == nil {
+		metaData = meta.Raw
+	}
+
+	var streamErr *jsonRecordedError
+
+	err = r.reader.Err()
+	if err != nil {
+		streamErr = newRecordedError(err)
+	}
+
+	r.recorder.Update(func() {
+		r.exchange.Rows = r.rows
+		r.exchange.MetaData = metaData
+		r.exchange.StreamError = streamErr
+	})
+}
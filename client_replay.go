@@ -0,0 +1,293 @@
+package cbcolumnar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/couchbase/gocbcore/v10"
+	"github.com/google/uuid"
+)
+
+const replayUnsupportedMessage = "operation is not supported when replaying recorded queries"
+
+// queryReplayer serves the query exchanges recorded by queryRecorder.
+type queryReplayer struct {
+	lock      sync.Mutex
+	exchanges []*jsonRecordedExchange
+	used      []bool
+}
+
+func newQueryReplayer(path string) (*queryReplayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recorded queries: %w", err)
+	}
+
+	var recording jsonRecording
+
+	err = json.Unmarshal(data, &recording)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal recorded queries: %s", err) // nolint: err113, errorlint
+	}
+
+	for _, exchange := range recording.Exchanges {
+		exchange.Request, err = canonicalJSON(exchange.Request)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &queryReplayer{
+		lock:      sync.Mutex{},
+		exchanges: recording.Exchanges,
+		used:      make([]bool, len(recording.Exchanges)),
+	}, nil
+}
+
+// Next returns the first exchange matching the request which has not yet been replayed.
+func (r *queryReplayer) Next(request json.RawMessage) (*jsonRecordedExchange, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for i, exchange := range r.exchanges {
+		if r.used[i] || !bytes.Equal(exchange.Request, request) {
+			continue
+		}
+
+		r.used[i] = true
+
+		return exchange, true
+	}
+
+	return nil, false
+}
+
+// replayClusterClient is a clusterClient which serves queries from a golden file written by
+// recordingClusterClient, without connecting to a cluster.
+type replayClusterClient struct {
+	replayer           *queryReplayer
+	defaultUnmarshaler Unmarshaler
+	logger             *clusterLogger
+}
+
+func newReplayClusterClient(path string, defaultUnmarshaler Unmarshaler, logger *clusterLogger) (*replayClusterClient, error) {
+	replayer, err := newQueryReplayer(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &replayClusterClient{
+		replayer:           replayer,
+		defaultUnmarshaler: defaultUnmarshaler,
+		logger:             logger,
+	}, nil
+}
+
+func (c *replayClusterClient) Database(name string) databaseClient {
+	return &replayDatabaseClient{
+		replayer:           c.replayer,
+		name:               name,
+		defaultUnmarshaler: c.defaultUnmarshaler,
+		logger:             c.logger,
+	}
+}
+
+func (c *replayClusterClient) QueryClient() queryClient {
+	return newReplayQueryClient(c.replayer, c.defaultUnmarshaler, nil, c.logger)
+}
+
+func (c *replayClusterClient) QueryHandleClient() queryHandleClient {
+	return replayQueryHandleClient{}
+}
+
+func (c *replayClusterClient) DiagnosticsClient() diagnosticsClient {
+	return replayDiagnosticsClient{}
+}
+
+func (c *replayClusterClient) Close() error {
+	return nil
+}
+
+type replayDatabaseClient struct {
+	replayer           *queryReplayer
+	name               string
+	defaultUnmarshaler Unmarshaler
+	logger             *clusterLogger
+}
+
+func (c *replayDatabaseClient) Name() string {
+	return c.name
+}
+
+func (c *replayDatabaseClient) Scope(name string) scopeClient {
+	return &replayScopeClient{
+		replayer:           c.replayer,
+		name:               name,
+		databaseName:       c.name,
+		defaultUnmarshaler: c.defaultUnmarshaler,
+		logger:             c.logger,
+	}
+}
+
+type replayScopeClient struct {
+	replayer           *queryReplayer
+	name               string
+	databaseName       string
+	defaultUnmarshaler Unmarshaler
+	logger             *clusterLogger
+}
+
+func (c *replayScopeClient) Name() string {
+	return c.name
+}
+
+func (c *replayScopeClient) QueryClient() queryClient {
+	return newReplayQueryClient(c.replayer, c.defaultUnmarshaler, &queryClientNamespace{
+		Database: c.databaseName,
+		Scope:    c.name,
+	}, c.logger)
+}
+
+type replayQueryClient struct {
+	replayer           *queryReplayer
+	defaultUnmarshaler Unmarshaler
+	namespace          *queryClientNamespace
+	logger             *clusterLogger
+}
+
+func newReplayQueryClient(replayer *queryReplayer, defaultUnmarshaler Unmarshaler,
+	namespace *queryClientNamespace, logger *clusterLogger) *replayQueryClient {
+	return &replayQueryClient{
+		replayer:           replayer,
+		defaultUnmarshaler: defaultUnmarshaler,
+		namespace:          namespace,
+		logger:             logger,
+	}
+}
+
+func (c *replayQueryClient) Query(ctx context.Context, statement string, opts *QueryOptions) (*QueryResult, error) {
+	request, err := newRecordedRequest(ctx, statement, opts, c.namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	exchange, ok := c.replayer.Next(request)
+	if !ok {
+		return nil, newColumnarError(statement, "", 0).
+			withMessage("no recorded query matches the request")
+	}
+
+	if exchange.Error != nil {
+		return nil, exchange.Error.toError()
+	}
+
+	unmarshaler := opts.Unmarshaler
+	if unmarshaler == nil {
+		unmarshaler = c.defaultUnmarshaler
+	}
+
+	return &QueryResult{
+		reader: &replayRowReader{
+			exchange: exchange,
+			index:    0,
+			logger:   c.logger,
+		},
+		unmarshaler: unmarshaler,
+		logger:      c.logger,
+		finished:    false,
+		closed:      false,
+	}, nil
+}
+
+func (c *replayQueryClient) StartQuery(_ context.Context, statement string, _ *QueryOptions) (*QueryHandle, error) {
+	return nil, newColumnarError(statement, "", 0).withMessage(replayUnsupportedMessage)
+}
+
+type replayRowReader struct {
+	exchange *jsonRecordedExchange
+	index    int
+	logger   *clusterLogger
+}
+
+func (r *replayRowReader) NextRow() []byte {
+	if r.index >= len(r.exchange.Rows) {
+		return nil
+	}
+
+	row := r.exchange.Rows[r.index]
+	r.index++
+
+	return row
+}
+
+func (r *replayRowReader) MetaData() (*QueryMetadata, error) {
+	if r.exchange.MetaData == nil {
+		return nil, errors.New("the recorded query has no meta-data") // nolint: err113
+	}
+
+	return parseQueryMetadata(r.exchange.MetaData, r.logger)
+}
+
+func (r *replayRowReader) Close() error {
+	r.index = len(r.exchange.Rows)
+
+	return nil
+}
+
+func (r *replayRowReader) Err() error {
+	if r.exchange.StreamError != nil && r.index >= len(r.exchange.Rows) {
+		return r.exchange.StreamError.toError()
+	}
+
+	return nil
+}
+
+// replayQueryHandleClient rejects all operations as query handles are not recorded.
+type replayQueryHandleClient struct{}
+
+func (c replayQueryHandleClient) StartQuery(_ context.Context, opts *gocbcore.ColumnarQueryOptions,
+	_ Unmarshaler) (*QueryHandle, error) {
+	statement, _ := opts.Payload["statement"].(string)
+
+	return nil, newColumnarError(statement, "", 0).withMessage(replayUnsupportedMessage)
+}
+
+func (c replayQueryHandleClient) Status(_ context.Context, handle *QueryHandle) (*QueryHandleStatus, error) {
+	return nil, newColumnarError(handle.statement, "", 0).withMessage(replayUnsupportedMessage)
+}
+
+func (c replayQueryHandleClient) FetchResults(_ context.Context, handle *QueryHandle) (*QueryResult, error) {
+	return nil, newColumnarError(handle.statement, "", 0).withMessage(replayUnsupportedMessage)
+}
+
+func (c replayQueryHandleClient) Discard(_ context.Context, handle *QueryHandle) error {
+	return newColumnarError(handle.statement, "", 0).withMessage(replayUnsupportedMessage)
+}
+
+func (c replayQueryHandleClient) CancelQuery(_ context.Context, _ string) error {
+	return newColumnarError("", "", 0).withMessage(replayUnsupportedMessage)
+}
+
+// replayDiagnosticsClient reports that there are no endpoints, as no connections are made when replaying.
+type replayDiagnosticsClient struct{}
+
+func (c replayDiagnosticsClient) Ping(_ context.Context) (*PingReport, error) {
+	return &PingReport{
+		ID:        uuid.NewString(),
+		SDK:       Identifier(),
+		Endpoints: nil,
+	}, nil
+}
+
+func (c replayDiagnosticsClient) Diagnostics(_ context.Context) (*DiagnosticsReport, error) {
+	return &DiagnosticsReport{
+		ID:        uuid.NewString(),
+		SDK:       Identifier(),
+		Endpoints: nil,
+	}, nil
+}
@@ -2,8 +2,6 @@ package cbcolumnar
 
 import (
 	"time"
-
-	"github.com/couchbase/gocbcore/v10"
 )
 
 type scopeClient interface {
@@ -12,17 +10,23 @@ type scopeClient interface {
 }
 
 type gocbcoreScopeClient struct {
-	agent                     *gocbcore.ColumnarAgent
+	agent                     *agentClient
+	handleClient              queryHandleClient
+	credentials               CredentialProvider
+	logger                    *clusterLogger
 	name                      string
 	databaseName              string
 	defaultServerQueryTimeout time.Duration
 	defaultUnmarshaler        Unmarshaler
 }
 
-func newGocbcoreScopeClient(agent *gocbcore.ColumnarAgent, name, databaseName string,
-	defaultServerQueryTimeout time.Duration, defaultUnmarshaler Unmarshaler) *gocbcoreScopeClient {
+func newGocbcoreScopeClient(agent *agentClient, handleClient queryHandleClient, credentials CredentialProvider,
+	logger *clusterLogger, name, databaseName string, defaultServerQueryTimeout time.Duration, defaultUnmarshaler Unmarshaler) *gocbcoreScopeClient {
 	return &gocbcoreScopeClient{
 		agent:                     agent,
+		handleClient:              handleClient,
+		credentials:               credentials,
+		logger:                    logger,
 		name:                      name,
 		databaseName:              databaseName,
 		defaultServerQueryTimeout: defaultServerQueryTimeout,
@@ -35,8 +39,9 @@ func (c *gocbcoreScopeClient) Name() string {
 }
 
 func (c *gocbcoreScopeClient) QueryClient() queryClient {
-	return newGocbcoreQueryClient(c.agent, c.defaultServerQueryTimeout, c.defaultUnmarshaler,
-		&gocbcoreQueryClientNamespace{
+	return newGocbcoreQueryClient(c.agent, c.handleClient, c.credentials, c.logger, c.defaultServerQueryTimeout,
+		c.defaultUnmarshaler,
+		&queryClientNamespace{
 			Database: c.databaseName,
 			Scope:    c.name,
 		})
@@ -1,24 +1,44 @@
 package cbcolumnar
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
+	"log/slog"
 	"net"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/couchbaselabs/gocbconnstr"
+	"github.com/google/uuid"
 )
 
 // Cluster is the main entry point for the SDK.
 // It is used to perform operations on the data against a Couchbase Columnar cluster.
 type Cluster struct {
 	client clusterClient
+	logger *clusterLogger
 }
 
 // NewCluster creates a new Cluster instance.
+// When the connection string specifies security.client_cert_path, the client certificate is used to authenticate
+// and credential must be an empty Credential.
 func NewCluster(connStr string, credential Credential, opts ...*ClusterOptions) (*Cluster, error) {
+	return NewClusterWithCredentialProvider(connStr, NewStaticCredentialProvider(credential), opts...)
+}
+
+// NewClusterWithCredentialProvider creates a new Cluster instance which fetches the credential used to
+// authenticate from the provider, allowing the credential to be rotated without recreating the Cluster.
+func NewClusterWithCredentialProvider(connStr string, provider CredentialProvider,
+	opts ...*ClusterOptions) (*Cluster, error) {
+	if provider == nil {
+		return nil, invalidArgumentError{
+			ArgumentName: "provider",
+			Reason:       "must not be nil",
+		}
+	}
+
 	connSpec, err := gocbconnstr.Parse(connStr)
 	if err != nil {
 		return nil, err
@@ -37,6 +57,20 @@ func NewCluster(connStr string, credential Credential, opts ...*ClusterOptions)
 		clusterOpts = NewClusterOptions()
 	}
 
+	if clusterOpts.LogRedactionLevel != nil && *clusterOpts.LogRedactionLevel > RedactFull {
+		return nil, invalidArgumentError{
+			ArgumentName: "LogRedactionLevel",
+			Reason:       "unknown value",
+		}
+	}
+
+	clusterID := clusterOpts.ClusterID
+	if clusterID == "" {
+		clusterID = uuid.NewString()
+	}
+
+	logger := newClusterLogger(clusterID, clusterOpts.Logger, clusterOpts.LogRedactionLevel)
+
 	connectTimeout := 10000 * time.Millisecond
 	queryTimeout := 10 * time.Minute
 	useSrv := true
@@ -51,6 +85,16 @@ func NewCluster(connStr string, credential Credential, opts ...*ClusterOptions)
 		securityOpts = NewSecurityOptions()
 	}
 
+	srvOpts := clusterOpts.SrvOptions
+	if srvOpts == nil {
+		srvOpts = NewSrvOptions()
+	}
+
+	thresholdOpts := clusterOpts.ThresholdLoggingOptions
+	if thresholdOpts == nil {
+		thresholdOpts = NewThresholdLoggingOptions()
+	}
+
 	if timeoutOpts.ConnectTimeout != nil {
 		connectTimeout = *timeoutOpts.ConnectTimeout
 	}
@@ -80,6 +124,30 @@ func NewCluster(connStr string, credential Credential, opts ...*ClusterOptions)
 		useSrv = val
 	}
 
+	if valStr, ok := fetchOption("srv.fail_on_lookup_error"); ok {
+		val, err := strconv.ParseBool(valStr)
+		if err != nil {
+			return nil, invalidArgumentError{
+				ArgumentName: "srv.fail_on_lookup_error",
+				Reason:       err.Error(),
+			}
+		}
+
+		srvOpts.FailOnLookupError = &val
+	}
+
+	if valStr, ok := fetchOption("srv.refresh_interval"); ok {
+		interval, err := time.ParseDuration(valStr)
+		if err != nil {
+			return nil, invalidArgumentError{
+				ArgumentName: "srv.refresh_interval",
+				Reason:       err.Error(),
+			}
+		}
+
+		srvOpts.RefreshInterval = &interval
+	}
+
 	if valStr, ok := fetchOption("timeout.connect_timeout"); ok {
 		duration, err := time.ParseDuration(valStr)
 		if err != nil {
@@ -106,8 +174,30 @@ func NewCluster(connStr string, credential Credential, opts ...*ClusterOptions)
 
 	if valStr, ok := fetchOption("security.trust_only_pem_file"); ok {
 		securityOpts.TrustOnly = TrustOnlyPemFile{
-			Path: valStr,
+			Path:           valStr,
+			ReloadInterval: 0,
+		}
+	}
+
+	if valStr, ok := fetchOption("security.trust_only_pem_file_reload_interval"); ok {
+		pemFile, ok := securityOpts.TrustOnly.(TrustOnlyPemFile)
+		if !ok {
+			return nil, invalidArgumentError{
+				ArgumentName: "trust_only_pem_file_reload_interval",
+				Reason:       "can only be used with trust_only_pem_file",
+			}
+		}
+
+		interval, err := time.ParseDuration(valStr)
+		if err != nil {
+			return nil, invalidArgumentError{
+				ArgumentName: "trust_only_pem_file_reload_interval",
+				Reason:       err.Error(),
+			}
 		}
+
+		pemFile.ReloadInterval = interval
+		securityOpts.TrustOnly = pemFile
 	}
 
 	if valStr, ok := fetchOption("security.disable_server_certificate_verification"); ok {
@@ -128,6 +218,45 @@ func NewCluster(connStr string, credential Credential, opts ...*ClusterOptions)
 		securityOpts.CipherSuites = split
 	}
 
+	if certPath, ok := fetchOption("security.client_cert_path"); ok {
+		// The certificate replaces the credential, so one must not have been provided as well.
+		static, isStatic := provider.(*StaticCredentialProvider)
+		if !isStatic || static.credential.UsernamePassword != nil || static.credential.Certificate != nil {
+			return nil, invalidArgumentError{
+				ArgumentName: "client_cert_path",
+				Reason:       "cannot be used with a credential or credential provider, an empty Credential must be used",
+			}
+		}
+
+		keyPath, ok := fetchOption("security.client_key_path")
+		if !ok {
+			keyPath = certPath
+		}
+
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return nil, invalidArgumentError{
+				ArgumentName: "client_cert_path",
+				Reason:       err.Error(),
+			}
+		}
+
+		provider = NewStaticCredentialProvider(NewTLSCertificateCredential(cert))
+	} else if _, ok := fetchOption("security.client_key_path"); ok {
+		return nil, invalidArgumentError{
+			ArgumentName: "client_key_path",
+			Reason:       "client_cert_path must also be specified",
+		}
+	}
+
+	// A static credential never changes, so it can be validated upfront rather than on first use.
+	if static, ok := provider.(*StaticCredentialProvider); ok {
+		err := validateCredential(static.credential)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	cipherSuites := make([]*tls.CipherSuite, len(securityOpts.CipherSuites))
 
 	for i, suite := range securityOpts.CipherSuites {
@@ -143,7 +272,8 @@ func NewCluster(connStr string, credential Credential, opts ...*ClusterOptions)
 
 		for _, unsupportedSuite := range tls.InsecureCipherSuites() {
 			if unsupportedSuite.Name == suite {
-				logWarnf("cipher suite %s is insecure, it is not recommended to use this", suite)
+				logger.WarnAttrs("Cipher suite is insecure, it is not recommended to use this",
+					slog.String(logAttrCipherSuite, suite))
 
 				s = unsupportedSuite
 
@@ -183,23 +313,75 @@ func NewCluster(connStr string, credential Credential, opts ...*ClusterOptions)
 		useSrv = false
 	}
 
+	thresholdLoggingEnabled := thresholdOpts.Enabled != nil && *thresholdOpts.Enabled
+	thresholdLoggingThreshold := 1 * time.Second
+	thresholdLoggingInterval := 10 * time.Second
+	thresholdLoggingSampleSize := uint32(10)
+
+	if thresholdOpts.Threshold != nil {
+		thresholdLoggingThreshold = *thresholdOpts.Threshold
+	}
+
+	if thresholdOpts.EmitInterval != nil {
+		if *thresholdOpts.EmitInterval <= 0 {
+			return nil, invalidArgumentError{
+				ArgumentName: "EmitInterval",
+				Reason:       "must be greater than 0",
+			}
+		}
+
+		thresholdLoggingInterval = *thresholdOpts.EmitInterval
+	}
+
+	if thresholdOpts.SampleSize != nil {
+		if *thresholdOpts.SampleSize == 0 {
+			return nil, invalidArgumentError{
+				ArgumentName: "SampleSize",
+				Reason:       "must be greater than 0",
+			}
+		}
+
+		thresholdLoggingSampleSize = *thresholdOpts.SampleSize
+	}
+
+	var srvRefreshInterval time.Duration
+
+	if srvOpts.RefreshInterval != nil {
+		if *srvOpts.RefreshInterval < 0 {
+			return nil, invalidArgumentError{
+				ArgumentName: "RefreshInterval",
+				Reason:       "must not be negative",
+			}
+		}
+
+		srvRefreshInterval = *srvOpts.RefreshInterval
+	}
+
 	if useSrv {
-		_, srvAddrs, err := net.LookupSRV("couchbases", "tcp", connSpec.Addresses[0].Host)
+		ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+		srvAddrs, err := lookupSrvAddresses(ctx, net.DefaultResolver.LookupSRV, connSpec.Addresses[0].Host)
+		cancel()
+
 		if err != nil {
-			if isLogRedactionLevelFull() {
-				logInfof("Failed to lookup SRV record: %s", redactSystemData(err))
-			} else {
-				logInfof("Failed to lookup SRV record: %s", err)
+			if srvOpts.FailOnLookupError != nil && *srvOpts.FailOnLookupError {
+				return nil, invalidArgumentError{
+					ArgumentName: "srv",
+					Reason:       fmt.Sprintf("failed to lookup SRV record: %s", err),
+				}
 			}
-		}
 
-		for _, srvAddrs := range srvAddrs {
-			addrs = append(addrs, address{
-				Host: strings.TrimSuffix(srvAddrs.Target, "."),
-				Port: int(srvAddrs.Port),
-			})
+			logger.InfoAttrs("Failed to lookup SRV record", logger.SystemDataAttr(logAttrHost, connSpec.Addresses[0].Host),
+				logger.ErrAttr(err))
 		}
+
+		addrs = srvAddrs
 	} else {
+		if srvRefreshInterval > 0 {
+			logger.Warnf("SRV refresh interval is ignored as SRV resolution is not in use")
+
+			srvRefreshInterval = 0
+		}
+
 		for _, addr := range connSpec.Addresses {
 			addrs = append(addrs, address{
 				Host: addr.Host,
@@ -213,21 +395,31 @@ func NewCluster(connStr string, credential Credential, opts ...*ClusterOptions)
 		unmarshaler = NewJSONUnmarshaler()
 	}
 
-	if clusterOpts.SecurityOptions.DisableServerCertificateVerification != nil && *clusterOpts.SecurityOptions.DisableServerCertificateVerification {
-		logWarnf("server certificate verification is disabled, this is insecure")
+	if securityOpts.DisableServerCertificateVerification != nil && *securityOpts.DisableServerCertificateVerification {
+		logger.Warnf("server certificate verification is disabled, this is insecure")
 	}
 
 	mgr, err := newClusterClient(clusterClientOptions{
 		Spec:                                 connSpec,
-		Credential:                           &credential,
+		CredentialProvider:                   provider,
 		ConnectTimeout:                       connectTimeout,
 		ServerQueryTimeout:                   queryTimeout,
 		TrustOnly:                            securityOpts.TrustOnly,
 		DisableServerCertificateVerification: securityOpts.DisableServerCertificateVerification,
 		CipherSuites:                         cipherSuites,
 		DisableSrv:                           !useSrv,
+		SrvRefreshInterval:                   srvRefreshInterval,
 		Addresses:                            addrs,
 		Unmarshaler:                          unmarshaler,
+		Tracer:                               clusterOpts.Tracer,
+		Meter:                                clusterOpts.Meter,
+		EnableThresholdLogging:               thresholdLoggingEnabled,
+		ThresholdLoggingThreshold:            thresholdLoggingThreshold,
+		ThresholdLoggingInterval:             thresholdLoggingInterval,
+		ThresholdLoggingSampleSize:           int(thresholdLoggingSampleSize),
+		RecordPath:                           clusterOpts.recordPath,
+		ReplayPath:                           clusterOpts.replayPath,
+		Logger:                               logger,
 	})
 	if err != nil {
 		return nil, err
@@ -235,6 +427,7 @@ func NewCluster(connStr string, credential Credential, opts ...*ClusterOptions)
 
 	c := &Cluster{
 		client: mgr,
+		logger: logger,
 	}
 
 	return c, nil
@@ -19,6 +19,12 @@ func (t TrustOnlyCapella) trustOnly() {}
 // TrustOnlyPemFile tells the SDK to trust only the PEM-encoded certificate(s) in the file at the given FS path.
 type TrustOnlyPemFile struct {
 	Path string
+
+	// ReloadInterval when greater than zero causes the SDK to check the file for changes at the interval,
+	// replacing the trusted certificates whenever the file is modified. Connections which are already
+	// established are unaffected. If the modified file cannot be parsed then a warning is logged and the
+	// existing certificates continue to be trusted.
+	ReloadInterval time.Duration
 }
 
 func (t TrustOnlyPemFile) trustOnly() {}
@@ -42,6 +48,15 @@ type TrustOnlySystem struct{}
 
 func (t TrustOnlySystem) trustOnly() {}
 
+// TrustOnlyCombined tells the SDK to trust the certificates from all of the given trust sources, such as the
+// Capella CA certificate(s) alongside a corporate CA certificate.
+// TrustOnlyCertificates and TrustOnlyPemFile with a ReloadInterval cannot be combined with other trust sources.
+type TrustOnlyCombined struct {
+	Sources []TrustOnly
+}
+
+func (t TrustOnlyCombined) trustOnly() {}
+
 // SecurityOptions specifies options for controlling security related
 // items such as TLS root certificates and verification skipping.
 type SecurityOptions struct {
@@ -74,6 +89,22 @@ func (opts *SecurityOptions) SetTrustOnly(trustOnly TrustOnly) *SecurityOptions
 	return opts
 }
 
+// AddTrustOnly adds a trust source to the TrustOnly field in SecurityOptions, such that the certificates from
+// both the existing trust source(s) and the added trust source are trusted.
+func (opts *SecurityOptions) AddTrustOnly(trustOnly TrustOnly) *SecurityOptions {
+	if opts.TrustOnly == nil {
+		opts.TrustOnly = trustOnly
+
+		return opts
+	}
+
+	opts.TrustOnly = TrustOnlyCombined{
+		Sources: []TrustOnly{opts.TrustOnly, trustOnly},
+	}
+
+	return opts
+}
+
 // SetDisableServerCertificateVerification sets the DisableServerCertificateVerification field in SecurityOptions.
 func (opts *SecurityOptions) SetDisableServerCertificateVerification(disabled bool) *SecurityOptions {
 	opts.DisableServerCertificateVerification = &disabled
@@ -123,6 +154,108 @@ func (opts *TimeoutOptions) SetQueryTimeout(timeout time.Duration) *TimeoutOptio
 	return opts
 }
 
+// SrvOptions specifies options for how the DNS SRV record for the connection string is resolved.
+// These options only apply when the connection string contains a single host without a port, and SRV
+// resolution has not been disabled via the srv connection string option.
+type SrvOptions struct {
+	// FailOnLookupError when true causes the cluster to fail to be created if the SRV record cannot be
+	// resolved or has no targets, rather than logging the failure and continuing.
+	// Default = false
+	FailOnLookupError *bool
+
+	// RefreshInterval when greater than zero causes the SDK to re-resolve the SRV record at the interval, and
+	// re-seed its connections to the cluster with the new targets whenever they change, so that nodes which are
+	// replaced are picked up without recreating the cluster. Queries which are in progress when the connections
+	// are re-seeded are unaffected. If the record cannot be resolved then a warning is logged and the existing
+	// connections continue to be used.
+	// Default = 0 (disabled)
+	RefreshInterval *time.Duration
+}
+
+// NewSrvOptions creates a new instance of SrvOptions.
+func NewSrvOptions() *SrvOptions {
+	return &SrvOptions{
+		FailOnLookupError: nil,
+		RefreshInterval:   nil,
+	}
+}
+
+// SetFailOnLookupError sets the FailOnLookupError field in SrvOptions.
+func (opts *SrvOptions) SetFailOnLookupError(fail bool) *SrvOptions {
+	opts.FailOnLookupError = &fail
+
+	return opts
+}
+
+// SetRefreshInterval sets the RefreshInterval field in SrvOptions.
+func (opts *SrvOptions) SetRefreshInterval(interval time.Duration) *SrvOptions {
+	opts.RefreshInterval = &interval
+
+	return opts
+}
+
+// ThresholdLoggingOptions specifies options for logging the queries which take longer than a threshold to complete.
+// Threshold logging is disabled unless Enabled is set. At each EmitInterval the slowest queries over the threshold are logged at info level as a JSON summary,
+// including the statement, client context ID and the client and server durations of each query. The statement is
+// redacted according to the log redaction level, and is omitted when the level is RedactFull.
+type ThresholdLoggingOptions struct {
+	// Enabled when true enables threshold logging.
+	// Default = false
+	Enabled *bool
+
+	// Threshold specifies how long a query executed via ExecuteQuery must take to complete, including reading
+	// all of its rows, for it to be logged.
+	// Default = 1 second
+	Threshold *time.Duration
+
+	// EmitInterval specifies how often the summary of slow queries is logged.
+	// Default = 10 seconds
+	EmitInterval *time.Duration
+
+	// SampleSize specifies the maximum number of queries included in each summary, the slowest queries are
+	// included.
+	// Default = 10
+	SampleSize *uint32
+}
+
+// NewThresholdLoggingOptions creates a new instance of ThresholdLoggingOptions.
+func NewThresholdLoggingOptions() *ThresholdLoggingOptions {
+	return &ThresholdLoggingOptions{
+		Enabled:      nil,
+		Threshold:    nil,
+		EmitInterval: nil,
+		SampleSize:   nil,
+	}
+}
+
+// SetEnabled sets the Enabled field in ThresholdLoggingOptions.
+func (opts *ThresholdLoggingOptions) SetEnabled(enabled bool) *ThresholdLoggingOptions {
+	opts.Enabled = &enabled
+
+	return opts
+}
+
+// SetThreshold sets the Threshold field in ThresholdLoggingOptions.
+func (opts *ThresholdLoggingOptions) SetThreshold(threshold time.Duration) *ThresholdLoggingOptions {
+	opts.Threshold = &threshold
+
+	return opts
+}
+
+// SetEmitInterval sets the EmitInterval field in ThresholdLoggingOptions.
+func (opts *ThresholdLoggingOptions) SetEmitInterval(interval time.Duration) *ThresholdLoggingOptions {
+	opts.EmitInterval = &interval
+
+	return opts
+}
+
+// SetSampleSize sets the SampleSize field in ThresholdLoggingOptions.
+func (opts *ThresholdLoggingOptions) SetSampleSize(size uint32) *ThresholdLoggingOptions {
+	opts.SampleSize = &size
+
+	return opts
+}
+
 // ClusterOptions specifies options for configuring the cluster.
 type ClusterOptions struct {
 	// TimeoutOptions specifies various operation timeouts.
@@ -131,8 +264,44 @@ type ClusterOptions struct {
 	// SecurityOptions specifies security related configuration options.
 	SecurityOptions *SecurityOptions
 
+	// SrvOptions specifies options for resolving the DNS SRV record for the connection string.
+	SrvOptions *SrvOptions
+
+	// ThresholdLoggingOptions specifies options for logging slow queries.
+	ThresholdLoggingOptions *ThresholdLoggingOptions
+
 	// Unmarshaler specifies the default unmarshaler to use for decoding query response rows.
 	Unmarshaler Unmarshaler
+
+	// Tracer specifies the RequestTracer used to create a "query" span for each query executed via ExecuteQuery,
+	// with a "dispatch_to_server" child span for each time the query is sent to the cluster. A
+	// "dispatch_to_server" span is also created for each request the SDK sends directly, such as those for
+	// query handles and Ping. Spans are created by the SDK only, the trace context is not sent to the cluster.
+	// By default no spans are created.
+	Tracer RequestTracer
+
+	// Meter specifies the Meter used to record metrics for each query executed via ExecuteQuery.
+	// By default no metrics are recorded.
+	Meter Meter
+
+	// Logger specifies the Logger used for messages relating to this Cluster, taking precedence over the logger
+	// set via SetLogger. By default the logger set via SetLogger is used.
+	// Messages logged by gocbcore, and those logged while decoding query results, are always written to the
+	// logger set via SetLogger.
+	Logger Logger
+
+	// LogRedactionLevel specifies the level with which messages relating to this Cluster should be redacted,
+	// taking precedence over the level set via SetLogRedactionLevel.
+	// By default the level set via SetLogRedactionLevel is used.
+	LogRedactionLevel *LogRedactLevel
+
+	// ClusterID specifies the identifier which is attached to all messages logged for this Cluster, allowing
+	// the messages for multiple Cluster instances to be told apart. By default a random identifier is used.
+	ClusterID string
+
+	// recordPath and replayPath can only be set via internal/hooks.
+	recordPath string
+	replayPath string
 }
 
 // NewClusterOptions creates a new instance of ClusterOptions.
@@ -147,7 +316,24 @@ func NewClusterOptions() *ClusterOptions {
 			DisableServerCertificateVerification: nil,
 			CipherSuites:                         nil,
 		},
-		Unmarshaler: nil,
+		SrvOptions: &SrvOptions{
+			FailOnLookupError: nil,
+			RefreshInterval:   nil,
+		},
+		ThresholdLoggingOptions: &ThresholdLoggingOptions{
+			Enabled:      nil,
+			Threshold:    nil,
+			EmitInterval: nil,
+			SampleSize:   nil,
+		},
+		Unmarshaler:       nil,
+		Tracer:            nil,
+		Meter:             nil,
+		Logger:            nil,
+		LogRedactionLevel: nil,
+		ClusterID:         "",
+		recordPath:        "",
+		replayPath:        "",
 	}
 }
 
@@ -165,6 +351,20 @@ func (co *ClusterOptions) SetSecurityOptions(securityOptions *SecurityOptions) *
 	return co
 }
 
+// SetSrvOptions sets the SrvOptions field in ClusterOptions.
+func (co *ClusterOptions) SetSrvOptions(srvOptions *SrvOptions) *ClusterOptions {
+	co.SrvOptions = srvOptions
+
+	return co
+}
+
+// SetThresholdLoggingOptions sets the ThresholdLoggingOptions field in ClusterOptions.
+func (co *ClusterOptions) SetThresholdLoggingOptions(thresholdLoggingOptions *ThresholdLoggingOptions) *ClusterOptions {
+	co.ThresholdLoggingOptions = thresholdLoggingOptions
+
+	return co
+}
+
 // SetUnmarshaler sets the Unmarshaler field in ClusterOptions.
 func (co *ClusterOptions) SetUnmarshaler(unmarshaler Unmarshaler) *ClusterOptions {
 	co.Unmarshaler = unmarshaler
@@ -172,11 +372,55 @@ func (co *ClusterOptions) SetUnmarshaler(unmarshaler Unmarshaler) *ClusterOption
 	return co
 }
 
+// SetTracer sets the Tracer field in ClusterOptions.
+func (co *ClusterOptions) SetTracer(tracer RequestTracer) *ClusterOptions {
+	co.Tracer = tracer
+
+	return co
+}
+
+// SetMeter sets the Meter field in ClusterOptions.
+func (co *ClusterOptions) SetMeter(meter Meter) *ClusterOptions {
+	co.Meter = meter
+
+	return co
+}
+
+// SetLogger sets the Logger field in ClusterOptions.
+func (co *ClusterOptions) SetLogger(logger Logger) *ClusterOptions {
+	co.Logger = logger
+
+	return co
+}
+
+// SetLogRedactionLevel sets the LogRedactionLevel field in ClusterOptions.
+func (co *ClusterOptions) SetLogRedactionLevel(level LogRedactLevel) *ClusterOptions {
+	co.LogRedactionLevel = &level
+
+	return co
+}
+
+// SetClusterID sets the ClusterID field in ClusterOptions.
+func (co *ClusterOptions) SetClusterID(id string) *ClusterOptions {
+	co.ClusterID = id
+
+	return co
+}
+
 func mergeClusterOptions(opts ...*ClusterOptions) *ClusterOptions {
 	clusterOpts := &ClusterOptions{
-		TimeoutOptions:  nil,
-		SecurityOptions: nil,
-		Unmarshaler:     nil,
+		TimeoutOptions:          nil,
+		SecurityOptions:         nil,
+		SrvOptions:              nil,
+		ThresholdLoggingOptions: nil,
+		Unmarshaler:             nil,
+		Tracer:                  nil,
+		Meter:                   nil,
+		Logger:                  nil,
+		LogRedactionLevel:       nil,
+		ClusterID:               "",
+		recordPath:              "",
+		replayPath:              "",
 	}
 
 	for _, opt := range opts {
@@ -223,9 +467,81 @@ func mergeClusterOptions(opts ...*ClusterOptions) *ClusterOptions {
 			}
 		}
 
+		if opt.SrvOptions != nil {
+			if clusterOpts.SrvOptions == nil {
+				clusterOpts.SrvOptions = &SrvOptions{
+					FailOnLookupError: nil,
+					RefreshInterval:   nil,
+				}
+			}
+
+			if opt.SrvOptions.FailOnLookupError != nil {
+				clusterOpts.SrvOptions.FailOnLookupError = opt.SrvOptions.FailOnLookupError
+			}
+
+			if opt.SrvOptions.RefreshInterval != nil {
+				clusterOpts.SrvOptions.RefreshInterval = opt.SrvOptions.RefreshInterval
+			}
+		}
+
+		if opt.ThresholdLoggingOptions != nil {
+			if clusterOpts.ThresholdLoggingOptions == nil {
+				clusterOpts.ThresholdLoggingOptions = &ThresholdLoggingOptions{
+					Enabled:      nil,
+					Threshold:    nil,
+					EmitInterval: nil,
+					SampleSize:   nil,
+				}
+			}
+
+			if opt.ThresholdLoggingOptions.Enabled != nil {
+				clusterOpts.ThresholdLoggingOptions.Enabled = opt.ThresholdLoggingOptions.Enabled
+			}
+
+			if opt.ThresholdLoggingOptions.Threshold != nil {
+				clusterOpts.ThresholdLoggingOptions.Threshold = opt.ThresholdLoggingOptions.Threshold
+			}
+
+			if opt.ThresholdLoggingOptions.EmitInterval != nil {
+				clusterOpts.ThresholdLoggingOptions.EmitInterval = opt.ThresholdLoggingOptions.EmitInterval
+			}
+
+			if opt.ThresholdLoggingOptions.SampleSize != nil {
+				clusterOpts.ThresholdLoggingOptions.SampleSize = opt.ThresholdLoggingOptions.SampleSize
+			}
+		}
+
 		if opt.Unmarshaler != nil {
 			clusterOpts.Unmarshaler = opt.Unmarshaler
 		}
+
+		if opt.Tracer != nil {
+			clusterOpts.Tracer = opt.Tracer
+		}
+
+		if opt.Meter != nil {
+			clusterOpts.Meter = opt.Meter
+		}
+
+		if opt.Logger != nil {
+			clusterOpts.Logger = opt.Logger
+		}
+
+		if opt.LogRedactionLevel != nil {
+			clusterOpts.LogRedactionLevel = opt.LogRedactionLevel
+		}
+
+		if opt.ClusterID != "" {
+			clusterOpts.ClusterID = opt.ClusterID
+		}
+
+		if opt.recordPath != "" {
+			clusterOpts.recordPath = opt.recordPath
+		}
+
+		if opt.replayPath != "" {
+			clusterOpts.replayPath = opt.replayPath
+		}
 	}
 
 	return clusterOpts
@@ -1,10 +1,15 @@
 package cbcolumnar_test
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	cbcolumnar "github.com/couchbase/gocbcolumnar"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestInvalidCipherSuites(t *testing.T) {
@@ -25,3 +30,85 @@ func TestNoScheme(t *testing.T) {
 
 	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
 }
+
+func TestInvalidClientCertPath(t *testing.T) {
+	_, err := cbcolumnar.NewCluster("couchbases://localhost?security.client_cert_path=/does/not/exist.pem",
+		cbcolumnar.Credential{}, DefaultOptions())
+
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestClientCertPathWithCredential(t *testing.T) {
+	certPEM, keyPEM := newClientCertificatePEM(t)
+
+	certPath := filepath.Join(t.TempDir(), "client.pem")
+	require.NoError(t, os.WriteFile(certPath, append(certPEM, keyPEM...), 0o600))
+
+	connStr := "couchbases://localhost?security.client_cert_path=" + certPath
+
+	_, err := cbcolumnar.NewCluster(connStr, cbcolumnar.NewCredential("username", "password"), DefaultOptions())
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+	assert.Contains(t, err.Error(), "client_cert_path")
+
+	_, err = cbcolumnar.NewClusterWithCredentialProvider(connStr,
+		cbcolumnar.NewEnvCredentialProvider("USERNAME", "PASSWORD"), DefaultOptions())
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+	assert.Contains(t, err.Error(), "client_cert_path")
+}
+
+func TestClientKeyPathWithoutCertPath(t *testing.T) {
+	_, err := cbcolumnar.NewCluster("couchbases://localhost?security.client_key_path=/does/not/exist.pem",
+		cbcolumnar.NewCredential("username", "password"), DefaultOptions())
+
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestEmptyCredential(t *testing.T) {
+	_, err := cbcolumnar.NewCluster("couchbases://localhost", cbcolumnar.Credential{}, DefaultOptions())
+
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestSrvFailOnLookupError(t *testing.T) {
+	opts := DefaultOptions().SetSrvOptions(cbcolumnar.NewSrvOptions().SetFailOnLookupError(true))
+	_, err := cbcolumnar.NewCluster("couchbases://cluster.invalid", cbcolumnar.NewCredential("username", "password"), opts)
+
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+
+	_, err = cbcolumnar.NewCluster("couchbases://cluster.invalid?srv.fail_on_lookup_error=true",
+		cbcolumnar.NewCredential("username", "password"), DefaultOptions())
+
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestInvalidSrvRefreshInterval(t *testing.T) {
+	_, err := cbcolumnar.NewCluster("couchbases://localhost?srv.refresh_interval=bad",
+		cbcolumnar.NewCredential("username", "password"), DefaultOptions())
+
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestInvalidLogRedactionLevel(t *testing.T) {
+	_, err := cbcolumnar.NewCluster("couchbases://localhost",
+		cbcolumnar.NewCredential("username", "password"),
+		DefaultOptions().SetLogRedactionLevel(cbcolumnar.RedactFull+1))
+
+	assert.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestWaitUntilReadyRetriesUntilDeadline(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster("couchbases://127.0.0.1:1?srv=false",
+		cbcolumnar.NewCredential("username", "password"), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	err = cluster.WaitUntilReady(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+}
@@ -1,5 +1,11 @@
 package cbcolumnar
 
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+)
+
 // UserPassPair represents a username and password pair.
 type UserPassPair struct {
 	Username string
@@ -7,13 +13,96 @@ type UserPassPair struct {
 }
 
 // Credential provides a way to specify credentials to the SDK.
+// Exactly one of UsernamePassword or Certificate should be set.
 type Credential struct {
 	UsernamePassword *UserPassPair
+
+	// Certificate is the client certificate presented during the TLS handshake, used to authenticate
+	// using mutual TLS.
+	Certificate *tls.Certificate
 }
 
 // NewCredential creates a new Credential with the specified username and password.
 func NewCredential(username, password string) Credential {
 	return Credential{
 		UsernamePassword: &UserPassPair{Username: username, Password: password},
+		Certificate:      nil,
+	}
+}
+
+// NewCertificateCredential creates a new Credential which authenticates using mutual TLS, with the client
+// certificate and private key provided as PEM encoded data.
+func NewCertificateCredential(certPEM, keyPEM []byte) (Credential, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return Credential{}, invalidArgumentError{
+			ArgumentName: "certPEM",
+			Reason:       fmt.Sprintf("failed to load client certificate: %s", err),
+		}
+	}
+
+	return NewTLSCertificateCredential(cert), nil
+}
+
+// NewCertificateCredentialFromFiles creates a new Credential which authenticates using mutual TLS, with the
+// client certificate and private key read from the PEM encoded files at the specified paths.
+// The certificate and key may be contained within the same file.
+func NewCertificateCredentialFromFiles(certPath, keyPath string) (Credential, error) {
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return Credential{}, invalidArgumentError{
+			ArgumentName: "certPath",
+			Reason:       fmt.Sprintf("failed to load client certificate: %s", err),
+		}
+	}
+
+	return NewTLSCertificateCredential(cert), nil
+}
+
+// NewTLSCertificateCredential creates a new Credential which authenticates using mutual TLS, presenting
+// the specified client certificate.
+func NewTLSCertificateCredential(cert tls.Certificate) Credential {
+	return Credential{
+		UsernamePassword: nil,
+		Certificate:      &cert,
 	}
 }
+
+// validateCredential checks that exactly one form of authentication is set on the credential.
+func validateCredential(credential Credential) error {
+	if credential.UsernamePassword == nil && credential.Certificate == nil {
+		return invalidArgumentError{
+			ArgumentName: "Credential",
+			Reason:       "either UsernamePassword or Certificate must be set",
+		}
+	}
+
+	if credential.UsernamePassword != nil && credential.Certificate != nil {
+		return invalidArgumentError{
+			ArgumentName: "Credential",
+			Reason:       "only one of UsernamePassword or Certificate can be set",
+		}
+	}
+
+	return nil
+}
+
+// credentialsEqual reports whether two credentials would authenticate in the same way.
+func credentialsEqual(a, b Credential) bool {
+	if (a.UsernamePassword == nil) != (b.UsernamePassword == nil) {
+		return false
+	}
+
+	if a.UsernamePassword != nil && *a.UsernamePassword != *b.UsernamePassword {
+		return false
+	}
+
+	// Certificates loaded separately are compared using their leaf certificates, as a provider may load the
+	// same certificate again each time the credential is required.
+	if a.Certificate == nil || b.Certificate == nil ||
+		len(a.Certificate.Certificate) == 0 || len(b.Certificate.Certificate) == 0 {
+		return a.Certificate == b.Certificate
+	}
+
+	return bytes.Equal(a.Certificate.Certificate[0], b.Certificate.Certificate[0])
+}
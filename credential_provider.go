@@ -0,0 +1,340 @@
+package cbcolumnar
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/couchbase/gocbcore/v10"
+)
+
+// CredentialProvider provides the credential used to authenticate with the cluster.
+// The provider is consulted each time a connection is established and each time a request is sent, which
+// allows credentials to be rotated without recreating the Cluster. Requests which are already in flight
+// are unaffected by a change of credential.
+// Implementations must be safe for concurrent use.
+type CredentialProvider interface {
+	// Credential returns the credential to use.
+	Credential(ctx context.Context) (Credential, error)
+
+	// Refresh is called when the server rejects a credential returned by the provider. Implementations
+	// which cache the credential should discard the cached value. If the credential returned by Credential
+	// changes as a result then the rejected request is retried once.
+	Refresh(ctx context.Context) error
+}
+
+// StaticCredentialProvider is a CredentialProvider which always provides the same credential.
+type StaticCredentialProvider struct {
+	credential Credential
+}
+
+// NewStaticCredentialProvider creates a new StaticCredentialProvider which provides the specified credential.
+func NewStaticCredentialProvider(credential Credential) *StaticCredentialProvider {
+	return &StaticCredentialProvider{
+		credential: credential,
+	}
+}
+
+// Credential returns the credential.
+func (p *StaticCredentialProvider) Credential(_ context.Context) (Credential, error) {
+	return p.credential, nil
+}
+
+// Refresh does nothing as the credential never changes.
+func (p *StaticCredentialProvider) Refresh(_ context.Context) error {
+	return nil
+}
+
+// EnvCredentialProvider is a CredentialProvider which reads the username and password from environment
+// variables. The variables are read each time the credential is required, so changes are picked up immediately.
+type EnvCredentialProvider struct {
+	usernameVar string
+	passwordVar string
+}
+
+// NewEnvCredentialProvider creates a new EnvCredentialProvider which reads the username and password from the
+// environment variables with the specified names.
+func NewEnvCredentialProvider(usernameVar, passwordVar string) *EnvCredentialProvider {
+	return &EnvCredentialProvider{
+		usernameVar: usernameVar,
+		passwordVar: passwordVar,
+	}
+}
+
+// Credential returns the credential read from the environment variables.
+func (p *EnvCredentialProvider) Credential(_ context.Context) (Credential, error) {
+	username, ok := os.LookupEnv(p.usernameVar)
+	if !ok {
+		return Credential{}, fmt.Errorf("environment variable %s is not set", p.usernameVar) // nolint: err113
+	}
+
+	password, ok := os.LookupEnv(p.passwordVar)
+	if !ok {
+		return Credential{}, fmt.Errorf("environment variable %s is not set", p.passwordVar) // nolint: err113
+	}
+
+	return NewCredential(username, password), nil
+}
+
+// Refresh does nothing as the environment variables are always read when the credential is required.
+func (p *EnvCredentialProvider) Refresh(_ context.Context) error {
+	return nil
+}
+
+type credentialFileState struct {
+	modTime time.Time
+	size    int64
+	data    string
+}
+
+// credentialFilesState is the state of both credential files, which is replaced as a whole so that the username
+// and password returned are always from the same read.
+type credentialFilesState struct {
+	username *credentialFileState
+	password *credentialFileState
+}
+
+// maxCredentialFileReadAttempts is how many times the credential files are read when the username file is
+// modified while the password file is being read.
+const maxCredentialFileReadAttempts = 3
+
+// FileCredentialProvider is a CredentialProvider which reads the username and password from files, such as
+// those written by a secrets manager. The files are re-read whenever they are modified. A single trailing
+// newline is removed from the contents of each file.
+type FileCredentialProvider struct {
+	usernamePath string
+	passwordPath string
+
+	lock  sync.Mutex
+	state *credentialFilesState
+}
+
+// NewFileCredentialProvider creates a new FileCredentialProvider which reads the username and password from
+// the files at the specified paths.
+func NewFileCredentialProvider(usernamePath, passwordPath string) *FileCredentialProvider {
+	return &FileCredentialProvider{
+		usernamePath: usernamePath,
+		passwordPath: passwordPath,
+		lock:         sync.Mutex{},
+		state:        nil,
+	}
+}
+
+// Credential returns the credential read from the files, re-reading any file which has been modified since
+// it was last read. The files are read again if the username file is modified while the password file is being
+// read, so that a username is not paired with a password from a different rotation.
+func (p *FileCredentialProvider) Credential(_ context.Context) (Credential, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	cached := p.state
+	if cached == nil {
+		cached = &credentialFilesState{
+			username: nil,
+			password: nil,
+		}
+	}
+
+	for range maxCredentialFileReadAttempts {
+		username, err := readCredentialFile(p.usernamePath, cached.username)
+		if err != nil {
+			return Credential{}, err
+		}
+
+		password, err := readCredentialFile(p.passwordPath, cached.password)
+		if err != nil {
+			return Credential{}, err
+		}
+
+		unchanged, err := readCredentialFile(p.usernamePath, username)
+		if err != nil {
+			return Credential{}, err
+		}
+
+		if unchanged != username {
+			cached = &credentialFilesState{
+				username: unchanged,
+				password: password,
+			}
+
+			continue
+		}
+
+		p.state = &credentialFilesState{
+			username: username,
+			password: password,
+		}
+
+		return NewCredential(username.data, password.data), nil
+	}
+
+	return Credential{}, errors.New("credential files were modified while being read") // nolint: err113
+}
+
+// Refresh discards the cached contents of the files, so that they are re-read the next time the credential
+// is required.
+func (p *FileCredentialProvider) Refresh(_ context.Context) error {
+	p.lock.Lock()
+	p.state = nil
+	p.lock.Unlock()
+
+	return nil
+}
+
+// readCredentialFile reads the file at path, unless it has not been modified since the cached state was read,
+// in which case cached is returned.
+func readCredentialFile(path string, cached *credentialFileState) (*credentialFileState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat credential file: %w", err)
+	}
+
+	if cached != nil && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credential file: %w", err)
+	}
+
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	data = bytes.TrimSuffix(data, []byte("\r"))
+
+	return &credentialFileState{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		data:    string(data),
+	}, nil
+}
+
+// fetchCredential fetches the credential from the provider, validating it.
+func fetchCredential(ctx context.Context, provider CredentialProvider) (Credential, error) {
+	credential, err := provider.Credential(ctx)
+	if err != nil {
+		return Credential{}, fmt.Errorf("failed to fetch credential: %w", err)
+	}
+
+	err = validateCredential(credential)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	return credential, nil
+}
+
+// refreshCredential refreshes the provider after the server has rejected a credential, returning whether
+// the credential has changed and so the request should be retried.
+func refreshCredential(ctx context.Context, provider CredentialProvider, logger *clusterLogger) bool {
+	before, err := fetchCredential(ctx, provider)
+	if err != nil {
+		logger.Debugf("Failed to fetch credential before refresh: %s", err)
+
+		return false
+	}
+
+	err = provider.Refresh(ctx)
+	if err != nil {
+		logger.Debugf("Failed to refresh credential: %s", err)
+
+		return false
+	}
+
+	after, err := fetchCredential(ctx, provider)
+	if err != nil {
+		logger.Debugf("Failed to fetch credential after refresh: %s", err)
+
+		return false
+	}
+
+	return !credentialsEqual(before, after)
+}
+
+// retryOnInvalidCredential runs fn, retrying it once if it fails due to the server rejecting the credential
+// and refreshing the provider results in a different credential.
+func retryOnInvalidCredential[T any](ctx context.Context, provider CredentialProvider, logger *clusterLogger,
+	fn func() (T, error)) (T, error) {
+	res, err := fn()
+	if err == nil || !errors.Is(err, ErrInvalidCredential) || !refreshCredential(ctx, provider, logger) {
+		return res, err
+	}
+
+	logger.Debugf("Retrying request after credential was refreshed")
+
+	return fn()
+}
+
+var errCertificateCredentials = errors.New("credentials are not provided when authenticating using a client certificate")
+
+// gocbcoreAuthProvider adapts a CredentialProvider into a gocbcore.AuthProvider.
+type gocbcoreAuthProvider struct {
+	provider CredentialProvider
+
+	// timeout is how long the provider is given to provide the credential, as gocbcore does not provide a
+	// context for the request which needs it.
+	timeout time.Duration
+
+	// endpoints when set records the analytics endpoints which credentials are requested for, and refuses the
+	// credentials while the endpoints are being discovered.
+	endpoints *agentEndpoints
+}
+
+func (p gocbcoreAuthProvider) credential() (Credential, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	return fetchCredential(ctx, p.provider)
+}
+
+func (p gocbcoreAuthProvider) SupportsTLS() bool {
+	return true
+}
+
+func (p gocbcoreAuthProvider) SupportsNonTLS() bool {
+	return false
+}
+
+func (p gocbcoreAuthProvider) Certificate(_ gocbcore.AuthCertRequest) (*tls.Certificate, error) {
+	credential, err := p.credential()
+	if err != nil {
+		return nil, err
+	}
+
+	return credential.Certificate, nil
+}
+
+// Credentials returns an empty username and password for key-value connections when authenticating using a
+// client certificate, which prevents gocbcore from attempting any further authentication. Credentials for the
+// analytics service are refused instead, as gocbcore always sends them with the request, so the agent never sends
+// a query which authenticates using a client certificate, see agentClient.QueryViaHTTP.
+func (p gocbcoreAuthProvider) Credentials(req gocbcore.AuthCredsRequest) ([]gocbcore.UserPassPair, error) {
+	if req.Service == gocbcore.CbasService && p.endpoints != nil && !p.endpoints.Requested(req.Endpoint) {
+		return nil, errEndpointDiscovery
+	}
+
+	credential, err := p.credential()
+	if err != nil {
+		return nil, err
+	}
+
+	if credential.UsernamePassword == nil {
+		if req.Service == gocbcore.CbasService {
+			return nil, errCertificateCredentials
+		}
+
+		return []gocbcore.UserPassPair{{
+			Username: "",
+			Password: "",
+		}}, nil
+	}
+
+	return []gocbcore.UserPassPair{{
+		Username: credential.UsernamePassword.Username,
+		Password: credential.UsernamePassword.Password,
+	}}, nil
+}
@@ -0,0 +1,63 @@
+package cbcolumnar_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvCredentialProvider(t *testing.T) {
+	t.Setenv("CBCOLUMNAR_TEST_USERNAME", "user")
+	t.Setenv("CBCOLUMNAR_TEST_PASSWORD", "pass")
+
+	provider := cbcolumnar.NewEnvCredentialProvider("CBCOLUMNAR_TEST_USERNAME", "CBCOLUMNAR_TEST_PASSWORD")
+
+	credential, err := provider.Credential(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, &cbcolumnar.UserPassPair{Username: "user", Password: "pass"}, credential.UsernamePassword)
+
+	t.Setenv("CBCOLUMNAR_TEST_PASSWORD", "rotated")
+
+	credential, err = provider.Credential(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "rotated", credential.UsernamePassword.Password)
+
+	_, err = cbcolumnar.NewEnvCredentialProvider("CBCOLUMNAR_TEST_USERNAME", "CBCOLUMNAR_TEST_MISSING").
+		Credential(context.Background())
+	require.Error(t, err)
+}
+
+func TestFileCredentialProvider(t *testing.T) {
+	dir := t.TempDir()
+	usernamePath := filepath.Join(dir, "username")
+	passwordPath := filepath.Join(dir, "password")
+
+	require.NoError(t, os.WriteFile(usernamePath, []byte("user\n"), 0o600))
+	require.NoError(t, os.WriteFile(passwordPath, []byte("pass"), 0o600))
+
+	provider := cbcolumnar.NewFileCredentialProvider(usernamePath, passwordPath)
+
+	credential, err := provider.Credential(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, &cbcolumnar.UserPassPair{Username: "user", Password: "pass"}, credential.UsernamePassword)
+
+	require.NoError(t, os.WriteFile(passwordPath, []byte("rotated\r\n"), 0o600))
+
+	credential, err = provider.Credential(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "rotated", credential.UsernamePassword.Password)
+
+	require.NoError(t, os.Remove(usernamePath))
+
+	_, err = provider.Credential(context.Background())
+	require.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package cbcolumnar_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClientCertificatePEM(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestNewCertificateCredential(t *testing.T) {
+	certPEM, keyPEM := newClientCertificatePEM(t)
+
+	credential, err := cbcolumnar.NewCertificateCredential(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	assert.Nil(t, credential.UsernamePassword)
+	require.NotNil(t, credential.Certificate)
+
+	leaf, err := x509.ParseCertificate(credential.Certificate.Certificate[0])
+	require.NoError(t, err)
+
+	assert.Equal(t, "client", leaf.Subject.CommonName)
+}
+
+func TestNewCertificateCredentialFromFiles(t *testing.T) {
+	certPEM, keyPEM := newClientCertificatePEM(t)
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "client.pem")
+	keyPath := filepath.Join(dir, "client.key")
+
+	require.NoError(t, os.WriteFile(certPath, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyPath, keyPEM, 0o600))
+
+	credential, err := cbcolumnar.NewCertificateCredentialFromFiles(certPath, keyPath)
+	require.NoError(t, err)
+
+	assert.NotNil(t, credential.Certificate)
+
+	// The certificate and key can also be contained within a single file.
+	combinedPath := filepath.Join(dir, "combined.pem")
+	require.NoError(t, os.WriteFile(combinedPath, append(certPEM, keyPEM...), 0o600))
+
+	credential, err = cbcolumnar.NewCertificateCredentialFromFiles(combinedPath, combinedPath)
+	require.NoError(t, err)
+
+	assert.NotNil(t, credential.Certificate)
+}
+
+func TestNewCertificateCredentialInvalid(t *testing.T) {
+	certPEM, _ := newClientCertificatePEM(t)
+
+	_, err := cbcolumnar.NewCertificateCredential(certPEM, []byte("not a key"))
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+
+	_, err = cbcolumnar.NewCertificateCredentialFromFiles("/does/not/exist.pem", "/does/not/exist.key")
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
@@ -0,0 +1,271 @@
+package cbcolumnar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PingState specifies the outcome of pinging an endpoint.
+type PingState string
+
+const (
+	// PingStateOk indicates that the endpoint was reachable and accepted the credential.
+	PingStateOk PingState = "ok"
+
+	// PingStateTimeout indicates that the endpoint did not respond in time.
+	PingStateTimeout PingState = "timeout"
+
+	// PingStateError indicates that an error occurred while pinging the endpoint, such as a connection
+	// failure or the credential being rejected.
+	PingStateError PingState = "error"
+)
+
+// PingTLSState describes the TLS connection used to ping an endpoint.
+type PingTLSState struct {
+	Version                string    `json:"version"`
+	CipherSuite            string    `json:"cipher_suite"`
+	ServerName             string    `json:"server_name"`
+	PeerCertificateSubject string    `json:"peer_certificate_subject,omitempty"`
+	PeerCertificateExpiry  time.Time `json:"peer_certificate_expiry"`
+}
+
+// EndpointPingReport is the outcome of pinging a single analytics endpoint.
+type EndpointPingReport struct {
+	Endpoint   string
+	State      PingState
+	Latency    time.Duration
+	StatusCode int
+
+	// TLS is the state of the TLS connection, or nil if no connection could be established.
+	TLS *PingTLSState
+
+	// Error is the error which occurred while pinging the endpoint, or nil if State is PingStateOk.
+	Error error
+}
+
+// MarshalJSON implements the Marshaler interface.
+func (r EndpointPingReport) MarshalJSON() ([]byte, error) {
+	var errStr string
+	if r.Error != nil {
+		errStr = r.Error.Error()
+	}
+
+	b, err := json.Marshal(struct {
+		Endpoint   string        `json:"endpoint"`
+		State      PingState     `json:"state"`
+		LatencyUs  int64         `json:"latency_us"`
+		StatusCode int           `json:"status_code,omitempty"`
+		TLS        *PingTLSState `json:"tls,omitempty"`
+		Error      string        `json:"error,omitempty"`
+	}{
+		Endpoint:   r.Endpoint,
+		State:      r.State,
+		LatencyUs:  r.Latency.Microseconds(),
+		StatusCode: r.StatusCode,
+		TLS:        r.TLS,
+		Error:      errStr,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal endpoint ping report: %s", err) // nolint: err113, errorlint
+	}
+
+	return b, nil
+}
+
+// PingReport is the outcome of pinging each of the analytics endpoints known to the SDK.
+type PingReport struct {
+	ID        string               `json:"id"`
+	SDK       string               `json:"sdk"`
+	Endpoints []EndpointPingReport `json:"endpoints"`
+}
+
+// Ok returns whether every endpoint was pinged successfully.
+func (r *PingReport) Ok() bool {
+	for _, endpoint := range r.Endpoints {
+		if endpoint.State != PingStateOk {
+			return false
+		}
+	}
+
+	return true
+}
+
+// firstError returns the first error encountered while pinging the endpoints.
+func (r *PingReport) firstError() error {
+	for _, endpoint := range r.Endpoints {
+		if endpoint.Error != nil {
+			return endpoint.Error
+		}
+	}
+
+	return nil
+}
+
+// Ping pings each of the analytics endpoints in the cluster config, reporting the latency, TLS state and any
+// errors for each. The endpoints are discovered from the cluster config when they have not been discovered
+// recently. An error is only returned if the endpoints could not be discovered or pinged at all, errors pinging
+// individual endpoints are contained within the report.
+// If the context does not specify a deadline then the connect timeout is used for discovering the endpoints, and
+// each endpoint is given the connect timeout to respond.
+func (c *Cluster) Ping(ctx context.Context) (*PingReport, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return c.client.DiagnosticsClient().Ping(ctx)
+}
+
+// WaitUntilReady blocks until every analytics endpoint in the cluster config is reachable and accepts the
+// credential, pinging the endpoints until they are ready or the context is done. Until the SDK has bootstrapped
+// against the cluster and discovered its endpoints, discovery is retried until the context is done.
+// If the credential is rejected then an error matching ErrInvalidCredential is returned immediately.
+func (c *Cluster) WaitUntilReady(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	backoff := 100 * time.Millisecond
+
+	for {
+		var lastErr error
+
+		report, err := c.Ping(ctx)
+
+		switch {
+		case err != nil:
+			lastErr = err
+		case report.Ok():
+			return nil
+		default:
+			lastErr = report.firstError()
+		}
+
+		if errors.Is(lastErr, ErrInvalidCredential) {
+			return lastErr
+		}
+
+		c.logger.Debugf("Cluster not yet ready, retrying in %s: %s", backoff, lastErr)
+
+		select {
+		case <-ctx.Done():
+			return newColumnarError("", "", 0).
+				withMessage(fmt.Sprintf("cluster was not ready before the context was done: %s", lastErr)).
+				withCause(ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > 1*time.Second {
+			backoff = 1 * time.Second
+		}
+	}
+}
+
+// EndpointSource specifies where an endpoint known to the SDK came from.
+type EndpointSource string
+
+const (
+	// EndpointSourceClusterConfig indicates that the endpoint is an analytics endpoint taken from the cluster config.
+	EndpointSourceClusterConfig EndpointSource = "cluster_config"
+
+	// EndpointSourceConfigured indicates that the endpoint is an address from the connection string, which the
+	// SDK bootstraps against.
+	EndpointSourceConfigured EndpointSource = "configured"
+
+	// EndpointSourceSrv indicates that the endpoint is a target of the SRV record named by the connection string,
+	// which the SDK bootstraps against.
+	EndpointSourceSrv EndpointSource = "srv"
+)
+
+// ConnectionState specifies the state of a connection to an analytics endpoint.
+type ConnectionState string
+
+const (
+	// ConnectionStateActive indicates that the connection is being used by a request.
+	ConnectionStateActive ConnectionState = "active"
+
+	// ConnectionStateIdle indicates that the connection is open but not being used by a request.
+	ConnectionStateIdle ConnectionState = "idle"
+)
+
+// ConnectionDiagnostics describes an open connection to an analytics endpoint.
+type ConnectionDiagnostics struct {
+	ID            uint64          `json:"id"`
+	Endpoint      string          `json:"endpoint"`
+	LocalAddress  string          `json:"local_address"`
+	RemoteAddress string          `json:"remote_address"`
+	State         ConnectionState `json:"state"`
+	ConnectedAt   time.Time       `json:"connected_at"`
+	LastActivity  time.Time       `json:"last_activity"`
+}
+
+// EndpointDiagnostics describes an endpoint known to the SDK and the connections open to it, see
+// Cluster.Diagnostics for which connections are included.
+type EndpointDiagnostics struct {
+	Endpoint string
+	Source   EndpointSource
+
+	// ResolvedAddresses are the addresses which the host of the endpoint currently resolves to.
+	ResolvedAddresses []string
+
+	// ResolveError is the error which occurred while resolving the host of the endpoint, if any.
+	ResolveError error
+
+	Connections []ConnectionDiagnostics
+}
+
+// MarshalJSON implements the Marshaler interface.
+func (d EndpointDiagnostics) MarshalJSON() ([]byte, error) {
+	var errStr string
+	if d.ResolveError != nil {
+		errStr = d.ResolveError.Error()
+	}
+
+	b, err := json.Marshal(struct {
+		Endpoint          string                  `json:"endpoint"`
+		Source            EndpointSource          `json:"source"`
+		ResolvedAddresses []string                `json:"resolved_addresses,omitempty"`
+		ResolveError      string                  `json:"resolve_error,omitempty"`
+		Connections       []ConnectionDiagnostics `json:"connections"`
+	}{
+		Endpoint:          d.Endpoint,
+		Source:            d.Source,
+		ResolvedAddresses: d.ResolvedAddresses,
+		ResolveError:      errStr,
+		Connections:       d.Connections,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal endpoint diagnostics: %s", err) // nolint: err113, errorlint
+	}
+
+	return b, nil
+}
+
+// DiagnosticsReport describes the endpoints known to the SDK and the connections open to them.
+type DiagnosticsReport struct {
+	ID        string                `json:"id"`
+	SDK       string                `json:"sdk"`
+	Endpoints []EndpointDiagnostics `json:"endpoints"`
+}
+
+// Diagnostics reports the endpoints known to the SDK, the addresses that each currently resolves to and the HTTP
+// connections open to each. Unlike Ping no requests are sent to the endpoints.
+//
+// The endpoints reported are the addresses which the SDK bootstraps against, either from the connection string or
+// the targets of its SRV record, followed by the analytics endpoints last discovered from the cluster config, by
+// Ping or when managing query handles, along with any analytics endpoint which a query has since been sent to.
+// The connections reported are those opened by the SDK for pinging endpoints, managing query handles and
+// cancelling queries, and those used by queries executed using ExecuteQuery. The underlying gocbcore agent does not
+// expose its connections, so a connection used by a query is reported from when the query is sent until it has
+// been idle for longer than the agent's idle connection timeout, and the connections used to bootstrap against the
+// cluster are not included.
+func (c *Cluster) Diagnostics(ctx context.Context) (*DiagnosticsReport, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return c.client.DiagnosticsClient().Diagnostics(ctx)
+}
@@ -29,6 +29,12 @@ var ErrClosed = errors.New("closed")
 // ErrUnmarshal occurs when an entity could not be unmarshalled.
 var ErrUnmarshal = errors.New("unmarshalling error")
 
+// ErrNoRows occurs when a query which was expected to return a single row did not return any rows.
+var ErrNoRows = errors.New("no rows in result set")
+
+// ErrMultipleRows occurs when a query which was expected to return a single row returned more than one row.
+var ErrMultipleRows = errors.New("multiple rows in result set")
+
 type columnarErrorDesc struct {
 	Code    uint32
 	Message string
@@ -0,0 +1,25 @@
+package cbcolumnar
+
+import (
+	"github.com/couchbase/gocbcolumnar/internal/hooks"
+)
+
+func init() {
+	hooks.SetRecordPath = func(opts any, path string) {
+		clusterOpts, ok := opts.(*ClusterOptions)
+		if !ok {
+			return
+		}
+
+		clusterOpts.recordPath = path
+	}
+
+	hooks.SetReplayPath = func(opts any, path string) {
+		clusterOpts, ok := opts.(*ClusterOptions)
+		if !ok {
+			return
+		}
+
+		clusterOpts.replayPath = path
+	}
+}
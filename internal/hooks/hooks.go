@@ -0,0 +1,13 @@
+// Package hooks allows other packages within this module to access functionality of the cbcolumnar
+// package which is not part of its public API.
+// The hooks are set by the cbcolumnar package when it is initialized, and so are always available
+// to any package which imports cbcolumnar.
+package hooks
+
+// SetRecordPath configures the given *cbcolumnar.ClusterOptions so that all queries executed by the cluster
+// are recorded, and written to the golden file at path when the cluster is closed.
+var SetRecordPath func(opts any, path string)
+
+// SetReplayPath configures the given *cbcolumnar.ClusterOptions so that queries are replayed from the golden
+// file at path, rather than being sent to a cluster.
+var SetReplayPath func(opts any, path string)
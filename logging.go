@@ -4,6 +4,7 @@ package cbcolumnar
 import (
 	"fmt"
 	"log"
+	"log/slog"
 	"strings"
 
 	"github.com/couchbase/gocbcore/v10"
@@ -39,6 +40,7 @@ const (
 )
 
 // SetLogRedactionLevel specifies the level with which logs should be redacted.
+// The level set via ClusterOptions.SetLogRedactionLevel takes precedence for messages relating to that Cluster.
 func SetLogRedactionLevel(level LogRedactLevel) {
 	globalLogRedactionLevel = level
 	gocbcore.SetLogRedactionLevel(gocbcore.LogRedactLevel(level))
@@ -71,6 +73,17 @@ type Logger interface {
 	Log(level LogLevel, offset int, format string, v ...interface{}) error
 }
 
+// StructuredLogger is a Logger which can also log messages with attributes. When the logger set via SetLogger
+// implements StructuredLogger then SDK events, such as a query being dispatched, are logged via LogAttrs with
+// attributes such as the endpoint and client context ID. Otherwise the attributes are appended to the message
+// as key=value pairs.
+type StructuredLogger interface {
+	Logger
+
+	// LogAttrs outputs the message with the attributes, with level and offset as for Log.
+	LogAttrs(level LogLevel, offset int, msg string, attrs ...slog.Attr) error
+}
+
 var (
 	globalLogger            Logger
 	globalLogRedactionLevel LogRedactLevel
@@ -97,8 +110,9 @@ func VerboseStdioLogger() Logger {
 }
 
 // SetLogger sets a logger to be used by the library. A logger can be obtained via
-// the DefaultStdioLogger() or VerboseStdioLogger() functions. You can also implement
-// your own logger using the Logger interface.
+// the DefaultStdioLogger(), VerboseStdioLogger() or NewSlogLogger() functions. You can also implement
+// your own logger using the Logger or StructuredLogger interfaces.
+// The logger set via ClusterOptions.SetLogger takes precedence for messages relating to that Cluster.
 func SetLogger(logger Logger) {
 	globalLogger = logger
 	gocbcore.SetLogger(getCoreLogger(logger))
@@ -134,6 +148,61 @@ func logErrorf(format string, v ...interface{}) {
 	logExf(LogError, 1, format, v...)
 }
 
+// The keys of the attributes attached to structured log messages.
+const (
+	logAttrClusterID            = "cluster_id"
+	logAttrEndpoint             = "endpoint"
+	logAttrClientContextID      = "client_context_id"
+	logAttrStatusCode           = "status_code"
+	logAttrHost                 = "host"
+	logAttrAddressCount         = "address_count"
+	logAttrPreviousAddressCount = "previous_address_count"
+	logAttrCipherSuite          = "cipher_suite"
+	logAttrError                = "error"
+)
+
+// writeLog writes the message with the attributes to the logger. Loggers which are not a StructuredLogger are
+// passed the original format and arguments, with a key=%s verb and argument appended for each attribute.
+func writeLog(logger Logger, level LogLevel, offset int, format string, v []interface{}, attrs []slog.Attr) {
+	var err error
+	if structuredLogger, ok := logger.(StructuredLogger); ok {
+		err = structuredLogger.LogAttrs(level, offset+1, fmt.Sprintf(format, v...), attrs...)
+	} else {
+		format, v = appendLogAttrs(format, v, attrs)
+		err = logger.Log(level, offset+1, format, v...)
+	}
+
+	if err != nil {
+		log.Printf("Logger error occurred (%s)\n", err)
+	}
+}
+
+// appendLogAttrs appends the attributes to the format and arguments as key=value pairs, for loggers which are
+// not structured.
+func appendLogAttrs(format string, v []interface{}, attrs []slog.Attr) (string, []interface{}) {
+	var sb strings.Builder
+
+	sb.WriteString(format)
+
+	args := make([]interface{}, 0, len(v)+len(attrs))
+	args = append(args, v...)
+
+	for _, attr := range attrs {
+		sb.WriteString(" ")
+		sb.WriteString(escapeLogFormat(attr.Key))
+		sb.WriteString("=%s")
+
+		args = append(args, attr.Value.String())
+	}
+
+	return sb.String(), args
+}
+
+// escapeLogFormat escapes msg so that it can be used as a format string.
+func escapeLogFormat(msg string) string {
+	return strings.ReplaceAll(msg, "%", "%%")
+}
+
 func reindentLog(indent, message string) string {
 	reindentedMessage := strings.ReplaceAll(message, "\n", "\n"+indent)
 
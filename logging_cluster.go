@@ -0,0 +1,101 @@
+package cbcolumnar
+
+import (
+	"log/slog"
+)
+
+// clusterLogger writes the messages relating to a single Cluster, using the logger and redaction level configured
+// for that Cluster if any, and otherwise those set via SetLogger and SetLogRedactionLevel. Every message is
+// tagged with the identifier of the Cluster.
+type clusterLogger struct {
+	id             string
+	logger         Logger
+	redactionLevel *LogRedactLevel
+}
+
+// newClusterLogger creates a clusterLogger for the Cluster identified by id. If logger or redactionLevel are nil
+// then the global logger or redaction level are used, as they are at the time of each message being logged.
+func newClusterLogger(id string, logger Logger, redactionLevel *LogRedactLevel) *clusterLogger {
+	return &clusterLogger{
+		id:             id,
+		logger:         logger,
+		redactionLevel: redactionLevel,
+	}
+}
+
+// RedactionLevel returns the level with which messages relating to the Cluster should be redacted.
+func (l *clusterLogger) RedactionLevel() LogRedactLevel {
+	if l.redactionLevel != nil {
+		return *l.redactionLevel
+	}
+
+	return globalLogRedactionLevel
+}
+
+// ErrAttr returns the attribute for err, redacted when the redaction level is full.
+func (l *clusterLogger) ErrAttr(err error) slog.Attr {
+	if l.RedactionLevel() == RedactFull {
+		return slog.String(logAttrError, redactSystemData(err))
+	}
+
+	return slog.String(logAttrError, err.Error())
+}
+
+// SystemDataAttr returns the attribute for the system data value, such as a hostname, redacted when the redaction
+// level is full.
+func (l *clusterLogger) SystemDataAttr(key, value string) slog.Attr {
+	if l.RedactionLevel() == RedactFull {
+		return slog.String(key, redactSystemDataString(value))
+	}
+
+	return slog.String(key, value)
+}
+
+func (l *clusterLogger) logEx(level LogLevel, offset int, msg string, attrs []slog.Attr) {
+	l.write(level, offset+1, escapeLogFormat(msg), nil, attrs)
+}
+
+func (l *clusterLogger) logExf(level LogLevel, offset int, format string, v ...interface{}) {
+	l.write(level, offset+1, format, v, nil)
+}
+
+func (l *clusterLogger) write(level LogLevel, offset int, format string, v []interface{}, attrs []slog.Attr) {
+	logger := l.logger
+	if logger == nil {
+		logger = globalLogger
+	}
+
+	if logger == nil {
+		return
+	}
+
+	writeLog(logger, level, offset+1, format, v, append([]slog.Attr{slog.String(logAttrClusterID, l.id)}, attrs...))
+}
+
+func (l *clusterLogger) Infof(format string, v ...interface{}) {
+	l.logExf(LogInfo, 1, format, v...)
+}
+
+func (l *clusterLogger) Debugf(format string, v ...interface{}) {
+	l.logExf(LogDebug, 1, format, v...)
+}
+
+func (l *clusterLogger) Warnf(format string, v ...interface{}) {
+	l.logExf(LogWarn, 1, format, v...)
+}
+
+func (l *clusterLogger) InfoAttrs(msg string, attrs ...slog.Attr) {
+	l.logEx(LogInfo, 1, msg, attrs)
+}
+
+func (l *clusterLogger) DebugAttrs(msg string, attrs ...slog.Attr) {
+	l.logEx(LogDebug, 1, msg, attrs)
+}
+
+func (l *clusterLogger) SchedAttrs(msg string, attrs ...slog.Attr) {
+	l.logEx(LogSched, 1, msg, attrs)
+}
+
+func (l *clusterLogger) WarnAttrs(msg string, attrs ...slog.Attr) {
+	l.logEx(LogWarn, 1, msg, attrs)
+}
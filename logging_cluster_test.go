@@ -0,0 +1,106 @@
+package cbcolumnar
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type formatLogger struct {
+	messages []string
+}
+
+func (l *formatLogger) Log(_ LogLevel, _ int, format string, v ...interface{}) error {
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+
+	return nil
+}
+
+func TestClusterLoggerUnstructured(t *testing.T) {
+	formatter := &formatLogger{messages: nil}
+	logger := newClusterLogger("cluster-1", formatter, nil)
+
+	logger.DebugAttrs("Dispatching query", slog.String(logAttrEndpoint, "https://localhost:18095"),
+		slog.String(logAttrClientContextID, "abc"))
+	logger.Infof("Loaded %d trusted certificates", 2)
+
+	assert.Equal(t, []string{
+		"Dispatching query cluster_id=cluster-1 endpoint=https://localhost:18095 client_context_id=abc",
+		"Loaded 2 trusted certificates cluster_id=cluster-1",
+	}, formatter.messages)
+}
+
+type recordingFormatLogger struct {
+	formats []string
+	args    [][]interface{}
+}
+
+func (l *recordingFormatLogger) Log(_ LogLevel, _ int, format string, v ...interface{}) error {
+	l.formats = append(l.formats, format)
+	l.args = append(l.args, v)
+
+	return nil
+}
+
+func TestClusterLoggerUnstructuredKeepsFormat(t *testing.T) {
+	recorder := &recordingFormatLogger{formats: nil, args: nil}
+	logger := newClusterLogger("cluster-1", recorder, nil)
+
+	logger.Warnf("server certificate verification is disabled, this is insecure")
+	logger.Infof("Loaded %d trusted certificates", 2)
+	logger.DebugAttrs("Query 100% complete", slog.String(logAttrClientContextID, "abc"))
+
+	assert.Equal(t, []string{
+		"server certificate verification is disabled, this is insecure cluster_id=%s",
+		"Loaded %d trusted certificates cluster_id=%s",
+		"Query 100%% complete cluster_id=%s client_context_id=%s",
+	}, recorder.formats)
+	assert.Equal(t, [][]interface{}{
+		{"cluster-1"},
+		{2, "cluster-1"},
+		{"cluster-1", "abc"},
+	}, recorder.args)
+}
+
+func TestClusterLoggerGlobalFallback(t *testing.T) {
+	global := &formatLogger{messages: nil}
+	clusterFormatter := &formatLogger{messages: nil}
+
+	prevLogger := globalLogger
+	globalLogger = global
+
+	t.Cleanup(func() {
+		globalLogger = prevLogger
+	})
+
+	newClusterLogger("cluster-1", nil, nil).Infof("first")
+	newClusterLogger("cluster-2", clusterFormatter, nil).Infof("second")
+
+	assert.Equal(t, []string{"first cluster_id=cluster-1"}, global.messages)
+	assert.Equal(t, []string{"second cluster_id=cluster-2"}, clusterFormatter.messages)
+}
+
+func TestClusterLoggerRedactionLevel(t *testing.T) {
+	prevLevel := globalLogRedactionLevel
+	globalLogRedactionLevel = RedactFull
+
+	t.Cleanup(func() {
+		globalLogRedactionLevel = prevLevel
+	})
+
+	err := errors.New("lookup failed")
+
+	logger := newClusterLogger("cluster-1", nil, nil)
+	assert.Equal(t, RedactFull, logger.RedactionLevel())
+	assert.Equal(t, "<sd>lookup failed</sd>", logger.ErrAttr(err).Value.String())
+	assert.Equal(t, "<sd>example.com</sd>", logger.SystemDataAttr(logAttrHost, "example.com").Value.String())
+
+	level := RedactNone
+	logger = newClusterLogger("cluster-1", nil, &level)
+	assert.Equal(t, RedactNone, logger.RedactionLevel())
+	assert.Equal(t, "lookup failed", logger.ErrAttr(err).Value.String())
+	assert.Equal(t, "example.com", logger.SystemDataAttr(logAttrHost, "example.com").Value.String())
+}
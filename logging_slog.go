@@ -0,0 +1,70 @@
+package cbcolumnar
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// SlogLogger is a StructuredLogger which writes to a *slog.Logger. The SDK log levels are mapped to the slog
+// levels, with LogTrace mapped to 4 below slog.LevelDebug and LogSched to 8 below slog.LevelDebug.
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger creates a logger which writes to the *slog.Logger, which can be set via SetLogger.
+//
+//	cbcolumnar.SetLogger(cbcolumnar.NewSlogLogger(slog.Default()))
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	return &SlogLogger{
+		logger: logger,
+	}
+}
+
+// Log writes the formatted message to the slog logger.
+func (l *SlogLogger) Log(level LogLevel, offset int, format string, v ...interface{}) error {
+	return l.log(level, offset+1, fmt.Sprintf(format, v...), nil)
+}
+
+// LogAttrs writes the message with the attributes to the slog logger.
+func (l *SlogLogger) LogAttrs(level LogLevel, offset int, msg string, attrs ...slog.Attr) error {
+	return l.log(level, offset+1, msg, attrs)
+}
+
+func (l *SlogLogger) log(level LogLevel, offset int, msg string, attrs []slog.Attr) error {
+	ctx := context.Background()
+
+	slogLevel := slogLevelFromLogLevel(level)
+	if !l.logger.Enabled(ctx, slogLevel) {
+		return nil
+	}
+
+	// Skip runtime.Callers and this function, and then the frames described by offset, so that the source of the
+	// record is the function which logged the message.
+	var pcs [1]uintptr
+	runtime.Callers(offset+2, pcs[:])
+
+	record := slog.NewRecord(time.Now(), slogLevel, msg, pcs[0])
+	record.AddAttrs(attrs...)
+
+	return l.logger.Handler().Handle(ctx, record) // nolint: wrapcheck
+}
+
+func slogLevelFromLogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LogError:
+		return slog.LevelError
+	case LogWarn:
+		return slog.LevelWarn
+	case LogInfo:
+		return slog.LevelInfo
+	case LogDebug:
+		return slog.LevelDebug
+	case LogTrace:
+		return slog.LevelDebug - 4
+	default:
+		return slog.LevelDebug - 8
+	}
+}
@@ -0,0 +1,99 @@
+package cbcolumnar
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSlogLogger(level slog.Level) (*SlogLogger, *bytes.Buffer) {
+	var buf bytes.Buffer
+
+	return NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		AddSource:   true,
+		Level:       level,
+		ReplaceAttr: nil,
+	}))), &buf
+}
+
+func decodeSlogRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var records []map[string]any
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func TestSlogLoggerLevels(t *testing.T) {
+	logger, buf := newTestSlogLogger(slog.LevelDebug - 4)
+
+	require.NoError(t, logger.Log(LogError, 0, "error %d", 1))
+	require.NoError(t, logger.Log(LogWarn, 0, "warn %d", 2))
+	require.NoError(t, logger.Log(LogInfo, 0, "info %d", 3))
+	require.NoError(t, logger.Log(LogDebug, 0, "debug %d", 4))
+	require.NoError(t, logger.Log(LogTrace, 0, "trace %d", 5))
+	require.NoError(t, logger.Log(LogSched, 0, "sched %d", 6))
+
+	records := decodeSlogRecords(t, buf)
+
+	// The sched message is below the level of the handler so is not written.
+	require.Len(t, records, 5)
+
+	expected := []struct {
+		level string
+		msg   string
+	}{
+		{level: "ERROR", msg: "error 1"},
+		{level: "WARN", msg: "warn 2"},
+		{level: "INFO", msg: "info 3"},
+		{level: "DEBUG", msg: "debug 4"},
+		{level: "DEBUG-4", msg: "trace 5"},
+	}
+
+	for i, record := range records {
+		assert.Equal(t, expected[i].level, record["level"])
+		assert.Equal(t, expected[i].msg, record["msg"])
+
+		source, ok := record["source"].(map[string]any)
+		require.True(t, ok)
+		assert.Equal(t, "github.com/couchbase/gocbcolumnar.TestSlogLoggerLevels", source["function"])
+	}
+}
+
+func TestSlogLoggerAttrs(t *testing.T) {
+	slogLogger, buf := newTestSlogLogger(slog.LevelDebug)
+	logger := newClusterLogger("cluster-1", slogLogger, nil)
+
+	logger.DebugAttrs("Dispatching query", slog.String(logAttrEndpoint, "https://localhost:18095"),
+		slog.String(logAttrClientContextID, "abc"))
+
+	records := decodeSlogRecords(t, buf)
+	require.Len(t, records, 1)
+
+	record := records[0]
+	assert.Equal(t, "Dispatching query", record["msg"])
+	assert.Equal(t, "cluster-1", record[logAttrClusterID])
+	assert.Equal(t, "https://localhost:18095", record[logAttrEndpoint])
+	assert.Equal(t, "abc", record[logAttrClientContextID])
+
+	// The source is the function which logged the message, rather than the logging helpers.
+	source, ok := record["source"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "github.com/couchbase/gocbcolumnar.TestSlogLoggerAttrs", source["function"])
+}
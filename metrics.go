@@ -0,0 +1,157 @@
+package cbcolumnar
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Meter creates the instruments used to record the metrics reported by the SDK. See the cbcolumnarotel and
+// cbcolumnarprom packages for OpenTelemetry and Prometheus implementations.
+//
+// The following metrics are recorded for each query executed via ExecuteQuery, tagged with db.name and
+// db.couchbase.scope, which are empty for queries executed against the Cluster:
+//
+//   - columnar.query.duration: the time in microseconds from the query being sent until all rows were read
+//     or the result was closed, recorded via a ValueRecorder.
+//   - columnar.query.server_elapsed_time: the elapsed time in microseconds reported by the server, recorded via
+//     a ValueRecorder once all rows have been read.
+//   - columnar.query.server_execution_time: the execution time in microseconds reported by the server, recorded
+//     via a ValueRecorder once all rows have been read.
+//   - columnar.query.rows: the number of rows read, recorded via a Counter.
+//   - columnar.query.bytes: the number of bytes of rows read, recorded via a Counter.
+//   - columnar.query.errors: the number of queries which failed, recorded via a Counter and additionally
+//     tagged with error.type, one of timeout, query, invalid_credential or other.
+type Meter interface {
+	// Counter returns the counter with the given name and tags.
+	Counter(name string, tags map[string]string) (Counter, error)
+
+	// ValueRecorder returns the value recorder, such as a histogram, with the given name and tags.
+	ValueRecorder(name string, tags map[string]string) (ValueRecorder, error)
+}
+
+// Counter is a metric which can only be incremented.
+type Counter interface {
+	IncrementBy(num uint64)
+}
+
+// ValueRecorder is a metric which records a distribution of values. All of the values recorded by the SDK are
+// durations in microseconds.
+type ValueRecorder interface {
+	RecordValue(val uint64)
+}
+
+// The names of the metrics recorded by the SDK.
+const (
+	meterNameQueryDuration            = "columnar.query.duration"
+	meterNameQueryServerElapsedTime   = "columnar.query.server_elapsed_time"
+	meterNameQueryServerExecutionTime = "columnar.query.server_execution_time"
+	meterNameQueryRows                = "columnar.query.rows"
+	meterNameQueryBytes               = "columnar.query.bytes"
+	meterNameQueryErrors              = "columnar.query.errors"
+)
+
+// The tags set on the metrics recorded by the SDK, in addition to the database and scope.
+const (
+	meterTagErrorType = "error.type"
+)
+
+// noopMeter is the Meter used when no meter is configured.
+type noopMeter struct{}
+
+func (m noopMeter) Counter(_ string, _ map[string]string) (Counter, error) {
+	return noopCounter{}, nil
+}
+
+func (m noopMeter) ValueRecorder(_ string, _ map[string]string) (ValueRecorder, error) {
+	return noopValueRecorder{}, nil
+}
+
+type noopCounter struct{}
+
+func (c noopCounter) IncrementBy(_ uint64) {}
+
+type noopValueRecorder struct{}
+
+func (r noopValueRecorder) RecordValue(_ uint64) {}
+
+// queryMetrics records the metrics for queries executed against a namespace.
+type queryMetrics struct {
+	meter    Meter
+	logger   *clusterLogger
+	database string
+	scope    string
+}
+
+func newQueryMetrics(meter Meter, logger *clusterLogger, namespace *queryClientNamespace) *queryMetrics {
+	metrics := &queryMetrics{
+		meter:    meter,
+		logger:   logger,
+		database: "",
+		scope:    "",
+	}
+
+	if namespace != nil {
+		metrics.database = namespace.Database
+		metrics.scope = namespace.Scope
+	}
+
+	return metrics
+}
+
+func (m *queryMetrics) tags() map[string]string {
+	return map[string]string{
+		spanAttribDatabase: m.database,
+		spanAttribScope:    m.scope,
+	}
+}
+
+func (m *queryMetrics) RecordDuration(name string, duration time.Duration) {
+	recorder, err := m.meter.ValueRecorder(name, m.tags())
+	if err != nil {
+		m.logger.Debugf("Failed to create value recorder %s: %s", name, err)
+
+		return
+	}
+
+	recorder.RecordValue(uint64(duration.Microseconds())) // #nosec G115
+}
+
+func (m *queryMetrics) Increment(name string, num uint64) {
+	counter, err := m.meter.Counter(name, m.tags())
+	if err != nil {
+		m.logger.Debugf("Failed to create counter %s: %s", name, err)
+
+		return
+	}
+
+	counter.IncrementBy(num)
+}
+
+func (m *queryMetrics) RecordError(err error) {
+	tags := m.tags()
+	tags[meterTagErrorType] = meterErrorType(err)
+
+	counter, err := m.meter.Counter(meterNameQueryErrors, tags)
+	if err != nil {
+		m.logger.Debugf("Failed to create counter %s: %s", meterNameQueryErrors, err)
+
+		return
+	}
+
+	counter.IncrementBy(1)
+}
+
+// meterErrorType categorizes the error for the error.type tag.
+func meterErrorType(err error) string {
+	switch {
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, ErrInvalidCredential):
+		return "invalid_credential"
+	case errors.Is(err, ErrQuery):
+		return "query"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,127 @@
+package cbcolumnar
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// pemFileWatcher polls a PEM file for changes, atomically replacing the pool of certificates whenever the
+// file is modified. If the modified file cannot be parsed then the existing pool continues to be used.
+type pemFileWatcher struct {
+	path     string
+	interval time.Duration
+	logger   *clusterLogger
+
+	pool    atomic.Pointer[x509.CertPool]
+	modTime time.Time
+	size    int64
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// newPemFileWatcher loads the certificates from the PEM file at path and starts polling the file for changes
+// at the interval. The watcher must be closed once it is no longer needed.
+func newPemFileWatcher(path string, interval time.Duration, logger *clusterLogger) (*pemFileWatcher, error) {
+	w := &pemFileWatcher{
+		path:     path,
+		interval: interval,
+		logger:   logger,
+		pool:     atomic.Pointer[x509.CertPool]{},
+		modTime:  time.Time{},
+		size:     0,
+		stopCh:   make(chan struct{}),
+		wg:       sync.WaitGroup{},
+	}
+
+	count, err := w.reload()
+	if err != nil {
+		return nil, newTrustOnlyError(TrustOnlyPemFile{Path: path, ReloadInterval: interval}, count, err)
+	}
+
+	logger.Debugf("Loaded %d trusted certificates from %s", count, path)
+
+	w.wg.Add(1)
+
+	go w.loop()
+
+	return w, nil
+}
+
+// Pool returns the most recently loaded pool of certificates.
+func (w *pemFileWatcher) Pool() *x509.CertPool {
+	return w.pool.Load()
+}
+
+// Close stops polling the file.
+func (w *pemFileWatcher) Close() {
+	close(w.stopCh)
+	w.wg.Wait()
+}
+
+func (w *pemFileWatcher) loop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		count, err := w.reload()
+		if err != nil {
+			w.logger.Warnf("Failed to reload trusted certificates from %s, continuing to use the existing certificates: %s",
+				w.path, err)
+
+			continue
+		}
+
+		if count > 0 {
+			w.logger.Infof("Reloaded %d trusted certificates from %s", count, w.path)
+		}
+	}
+}
+
+// reload reads and parses the file if it has changed since it was last read, returning the number of
+// certificates loaded, or zero if the file has not changed. If the file fails to parse then the number of
+// certificates which were parsed before the failure is returned alongside the error.
+func (w *pemFileWatcher) reload() (int, error) {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat pem file %w", err)
+	}
+
+	if w.pool.Load() != nil && info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return 0, nil
+	}
+
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read pem file %w", err)
+	}
+
+	pool := x509.NewCertPool()
+
+	count, err := appendPemCertificates(pool, data)
+	if err != nil {
+		// Record the state of the file so that the same invalid file is not repeatedly reported.
+		w.modTime = info.ModTime()
+		w.size = info.Size()
+
+		return count, err
+	}
+
+	w.pool.Store(pool)
+	w.modTime = info.ModTime()
+	w.size = info.Size()
+
+	return count, nil
+}
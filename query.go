@@ -2,6 +2,22 @@ package cbcolumnar
 
 import (
 	"context"
+	"encoding/json"
+)
+
+// Queryable is implemented by the entities which queries can be executed against, Cluster and Scope.
+// It allows applications to depend on an interface rather than a concrete type, for example to
+// substitute a fake or to wrap query execution.
+// Any query entry points added to Cluster and Scope in the future will also be added to Queryable.
+type Queryable interface {
+	ExecuteQuery(ctx context.Context, statement string, opts ...*QueryOptions) (*QueryResult, error)
+	StartQuery(ctx context.Context, statement string, opts ...*QueryOptions) (*QueryHandle, error)
+	ExplainQuery(ctx context.Context, statement string, opts ...*QueryOptions) (*QueryPlan, error)
+}
+
+var (
+	_ Queryable = (*Cluster)(nil)
+	_ Queryable = (*Scope)(nil)
 )
 
 // ExecuteQuery executes the query statement on the server.
@@ -30,6 +46,204 @@ func (s *Scope) ExecuteQuery(ctx context.Context, statement string, opts ...*Que
 	return s.client.QueryClient().Query(ctx, statement, queryOpts)
 }
 
+// ExecuteQueryAs executes the query statement against the Queryable and buffers all rows into memory,
+// unmarshalling each row into a value of type T. Any meta-data from the query is also returned.
+func ExecuteQueryAs[T any](ctx context.Context, queryable Queryable, statement string, opts ...*QueryOptions) ([]T, *QueryMetadata, error) {
+	if queryable == nil {
+		return nil, nil, invalidArgumentError{
+			ArgumentName: "queryable",
+			Reason:       "queryable cannot be nil",
+		}
+	}
+
+	res, err := queryable.ExecuteQuery(ctx, statement, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return BufferQueryResult[T](res)
+}
+
+// ExecuteQueryOne executes the query statement against the Queryable and unmarshals the single row
+// returned into a value of type T.
+// If the query returns no rows then ErrNoRows is returned, if it returns more than one row then
+// ErrMultipleRows is returned and the result is closed.
+func ExecuteQueryOne[T any](ctx context.Context, queryable Queryable, statement string, opts ...*QueryOptions) (T, error) {
+	var zero T
+
+	if queryable == nil {
+		return zero, invalidArgumentError{
+			ArgumentName: "queryable",
+			Reason:       "queryable cannot be nil",
+		}
+	}
+
+	res, err := queryable.ExecuteQuery(ctx, statement, opts...)
+	if err != nil {
+		return zero, err
+	}
+
+	row := res.NextRow()
+	if row == nil {
+		err = res.Err()
+		if err != nil {
+			return zero, err
+		}
+
+		return zero, ErrNoRows
+	}
+
+	if res.NextRow() != nil {
+		err = res.Close()
+		if err != nil {
+			res.logger.Debugf("Failed to close query result with multiple rows: %s", err)
+		}
+
+		return zero, ErrMultipleRows
+	}
+
+	err = res.Err()
+	if err != nil {
+		return zero, err
+	}
+
+	var value T
+
+	err = row.ContentAs(&value)
+	if err != nil {
+		return zero, err
+	}
+
+	return value, nil
+}
+
+// StartQuery submits the query statement to the server to be executed asynchronously, returning
+// a handle which can be used to check the status of the query and to fetch the results.
+// The context.Context only applies to submitting the query, the Cluster level QueryTimeout is
+// always used as the server side timeout for executing the query.
+func (c *Cluster) StartQuery(ctx context.Context, statement string, opts ...*QueryOptions) (*QueryHandle, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	queryOpts := mergeQueryOptions(opts...)
+
+	return c.client.QueryClient().StartQuery(ctx, statement, queryOpts)
+}
+
+// StartQuery submits the query statement to the server to be executed asynchronously, tying the query
+// context to this Scope. A handle is returned which can be used to check the status of the query and
+// to fetch the results.
+// The context.Context only applies to submitting the query, the Cluster level QueryTimeout is
+// always used as the server side timeout for executing the query.
+func (s *Scope) StartQuery(ctx context.Context, statement string, opts ...*QueryOptions) (*QueryHandle, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	queryOpts := mergeQueryOptions(opts...)
+
+	return s.client.QueryClient().StartQuery(ctx, statement, queryOpts)
+}
+
+// ExplainQuery fetches the plan that the server would use to execute the query statement, without executing it.
+// When ExplainQuery is called with no context.Context, or a context.Context with no Deadline, then
+// the Cluster level QueryTimeout will be applied.
+func (c *Cluster) ExplainQuery(ctx context.Context, statement string, opts ...*QueryOptions) (*QueryPlan, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	queryOpts := mergeQueryOptions(opts...)
+
+	return explainQuery(ctx, c.client.QueryClient(), statement, queryOpts)
+}
+
+// ExplainQuery fetches the plan that the server would use to execute the query statement, tying the query
+// context to this Scope, without executing it.
+// When ExplainQuery is called with no context.Context, or a context.Context with no Deadline, then
+// the Cluster level QueryTimeout will be applied.
+func (s *Scope) ExplainQuery(ctx context.Context, statement string, opts ...*QueryOptions) (*QueryPlan, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	queryOpts := mergeQueryOptions(opts...)
+
+	return explainQuery(ctx, s.client.QueryClient(), statement, queryOpts)
+}
+
+func explainQuery(ctx context.Context, client queryClient, statement string, opts *QueryOptions) (*QueryPlan, error) {
+	raw := make(map[string]interface{}, len(opts.Raw)+1)
+	for k, v := range opts.Raw {
+		raw[k] = v
+	}
+
+	raw["plan-format"] = "JSON"
+	opts.Raw = raw
+
+	res, err := client.Query(ctx, "EXPLAIN "+statement, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	planBytes := res.reader.NextRow()
+
+	for res.reader.NextRow() != nil { // nolint: revive
+		// We only expect a single row, but need to drain the stream to check for errors.
+	}
+
+	err = res.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	if planBytes == nil {
+		return nil, newColumnarError("EXPLAIN "+statement, "", 0).withMessage("server did not return a query plan")
+	}
+
+	// Depending on the server version the plan may be returned as a JSON encoded string.
+	var planStr string
+	if json.Unmarshal(planBytes, &planStr) == nil {
+		planBytes = []byte(planStr)
+	}
+
+	plan := &QueryPlan{
+		Operator:         "",
+		OperatorID:       "",
+		PhysicalOperator: "",
+		ExecutionMode:    "",
+		DataSource:       "",
+		Inputs:           nil,
+		Raw:              nil,
+	}
+
+	err = plan.fromData(planBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// CancelQuery requests that the server cancels the query with the given client context ID.
+// The client context ID of a query can be specified using QueryOptions.SetClientContextID, allowing
+// queries to be cancelled from a different goroutine or process than the one that started them.
+func (c *Cluster) CancelQuery(ctx context.Context, clientContextID string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if clientContextID == "" {
+		return invalidArgumentError{
+			ArgumentName: "clientContextID",
+			Reason:       "cannot be empty",
+		}
+	}
+
+	return c.client.QueryHandleClient().CancelQuery(ctx, clientContextID)
+}
+
 func mergeQueryOptions(opts ...*QueryOptions) *QueryOptions {
 	queryOpts := &QueryOptions{
 		Priority:             nil,
@@ -39,6 +253,8 @@ func mergeQueryOptions(opts ...*QueryOptions) *QueryOptions {
 		ScanConsistency:      nil,
 		Raw:                  nil,
 		Unmarshaler:          nil,
+		Profile:              nil,
+		ClientContextID:      "",
 	}
 
 	for _, opt := range opts {
@@ -73,6 +289,14 @@ func mergeQueryOptions(opts ...*QueryOptions) *QueryOptions {
 		if opt.Unmarshaler != nil {
 			queryOpts.Unmarshaler = opt.Unmarshaler
 		}
+
+		if opt.Profile != nil {
+			queryOpts.Profile = opt.Profile
+		}
+
+		if opt.ClientContextID != "" {
+			queryOpts.ClientContextID = opt.ClientContextID
+		}
 	}
 
 	return queryOpts
@@ -0,0 +1,181 @@
+package cbcolumnar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+)
+
+const (
+	queryHandleStatusPathPrefix = "/api/v1/request/status/"
+	queryHandleResultPathPrefix = "/api/v1/request/result/"
+)
+
+// QueryHandleStatus provides the status of a query which was started using StartQuery.
+type QueryHandleStatus struct {
+	Status    QueryStatus
+	RequestID string
+	Metrics   QueryMetrics
+}
+
+// QueryHandle represents a query which has been submitted to the server to be executed asynchronously.
+// The handle can be serialized using json.Marshal and later loaded using Cluster.LoadQueryHandle,
+// allowing the results to be fetched from a different process than the one that started the query.
+type QueryHandle struct {
+	client queryHandleClient
+
+	requestID    string
+	endpoint     string
+	statusHandle string
+	statement    string
+
+	// lock guards resultHandle, which is set once the status of the query reports that it has completed.
+	lock         sync.Mutex
+	resultHandle string
+
+	unmarshaler Unmarshaler
+}
+
+type jsonQueryHandle struct {
+	RequestID    string `json:"requestID"`
+	Endpoint     string `json:"endpoint"`
+	StatusHandle string `json:"statusHandle"`
+	ResultHandle string `json:"resultHandle,omitempty"`
+}
+
+// RequestID returns the server assigned request ID of the query.
+func (h *QueryHandle) RequestID() string {
+	return h.requestID
+}
+
+func (h *QueryHandle) loadResultHandle() string {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.resultHandle
+}
+
+func (h *QueryHandle) storeResultHandle(resultHandle string) {
+	h.lock.Lock()
+	h.resultHandle = resultHandle
+	h.lock.Unlock()
+}
+
+// Status fetches the current status of the query from the server.
+// If the query failed on the server then the error returned will describe the failure.
+func (h *QueryHandle) Status(ctx context.Context) (*QueryHandleStatus, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return h.client.Status(ctx, h)
+}
+
+// FetchResults fetches the results of the query from the server, waiting for the query to
+// complete if it is still executing.
+func (h *QueryHandle) FetchResults(ctx context.Context) (*QueryResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return h.client.FetchResults(ctx, h)
+}
+
+// Discard informs the server that the results of the query are no longer required,
+// allowing them to be released.
+func (h *QueryHandle) Discard(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return h.client.Discard(ctx, h)
+}
+
+// MarshalJSON serializes the handle so that it can later be loaded using Cluster.LoadQueryHandle.
+func (h *QueryHandle) MarshalJSON() ([]byte, error) {
+	// We don't need to convert this error, marshalling strings cannot fail.
+	return json.Marshal(jsonQueryHandle{ // nolint: wrapcheck
+		RequestID:    h.requestID,
+		Endpoint:     h.endpoint,
+		StatusHandle: h.statusHandle,
+		ResultHandle: h.loadResultHandle(),
+	})
+}
+
+// LoadQueryHandle loads a QueryHandle which was previously serialized using json.Marshal.
+// The endpoint of the handle must be one of the analytics endpoints of the cluster, the handle is rejected
+// before any request is sent if it is not.
+func (c *Cluster) LoadQueryHandle(data []byte) (*QueryHandle, error) {
+	var jsonHandle jsonQueryHandle
+
+	err := json.Unmarshal(data, &jsonHandle)
+	if err != nil {
+		return nil, invalidArgumentError{
+			ArgumentName: "data",
+			Reason:       err.Error(),
+		}
+	}
+
+	if jsonHandle.Endpoint == "" || jsonHandle.StatusHandle == "" {
+		return nil, invalidArgumentError{
+			ArgumentName: "data",
+			Reason:       "query handle is missing endpoint or status handle",
+		}
+	}
+
+	err = validateQueryHandleEndpoint(jsonHandle.Endpoint)
+	if err == nil {
+		err = validateQueryHandlePath(jsonHandle.StatusHandle, queryHandleStatusPathPrefix)
+	}
+
+	if err == nil && jsonHandle.ResultHandle != "" {
+		err = validateQueryHandlePath(jsonHandle.ResultHandle, queryHandleResultPathPrefix)
+	}
+
+	if err != nil {
+		return nil, invalidArgumentError{
+			ArgumentName: "data",
+			Reason:       err.Error(),
+		}
+	}
+
+	return &QueryHandle{
+		client:       c.client.QueryHandleClient(),
+		requestID:    jsonHandle.RequestID,
+		endpoint:     jsonHandle.Endpoint,
+		statusHandle: jsonHandle.StatusHandle,
+		statement:    "",
+		lock:         sync.Mutex{},
+		resultHandle: jsonHandle.ResultHandle,
+		unmarshaler:  nil,
+	}, nil
+}
+
+// validateQueryHandleEndpoint checks that the endpoint is the base URL of an analytics endpoint.
+func validateQueryHandleEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("query handle endpoint is invalid: %s", err) // nolint: err113, errorlint
+	}
+
+	if u.Scheme != "https" || u.Host == "" || u.User != nil || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("query handle endpoint %s is not an analytics endpoint", endpoint) // nolint: err113
+	}
+
+	return nil
+}
+
+// validateQueryHandlePath checks that the status or result handle is a path beneath prefix, so that it cannot be
+// used to send requests to any other REST endpoint.
+func validateQueryHandlePath(handlePath, prefix string) error {
+	if !strings.HasPrefix(handlePath, prefix) || len(handlePath) == len(prefix) ||
+		strings.ContainsAny(handlePath, "?#%") || path.Clean(handlePath) != handlePath {
+		return fmt.Errorf("query handle path %s must be beneath %s", handlePath, prefix) // nolint: err113
+	}
+
+	return nil
+}
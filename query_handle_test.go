@@ -0,0 +1,135 @@
+package cbcolumnar_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartQuery(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	startQueryAgainst := []cbcolumnar.Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}
+
+	for _, queryable := range startQueryAgainst {
+		t.Run(reflect.TypeOf(queryable).Elem().String(), func(tt *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			handle, err := queryable.StartQuery(ctx, "FROM RANGE(0, 99) AS i SELECT RAW i")
+			require.NoError(tt, err)
+
+			assert.NotEmpty(tt, handle.RequestID())
+
+			status, err := handle.Status(ctx)
+			require.NoError(tt, err)
+
+			assert.NotEmpty(tt, status.Status)
+
+			res, err := handle.FetchResults(ctx)
+			require.NoError(tt, err)
+
+			actualRows := CollectRows[int](tt, res)
+			require.Len(tt, actualRows, 100)
+
+			for i := 0; i < 100; i++ {
+				require.Equal(tt, i, actualRows[i])
+			}
+
+			require.NoError(tt, res.Err())
+
+			data, err := json.Marshal(handle)
+			require.NoError(tt, err)
+
+			loaded, err := cluster.LoadQueryHandle(data)
+			require.NoError(tt, err)
+
+			assert.Equal(tt, handle.RequestID(), loaded.RequestID())
+
+			status, err = loaded.Status(ctx)
+			require.NoError(tt, err)
+
+			assert.Equal(tt, cbcolumnar.QueryStatusSuccess, status.Status)
+
+			err = loaded.Discard(ctx)
+			require.NoError(tt, err)
+		})
+	}
+}
+
+func TestLoadQueryHandleInNewCluster(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	handle, err := cluster.StartQuery(ctx, "FROM RANGE(0, 9) AS i SELECT RAW i")
+	require.NoError(t, err)
+
+	data, err := json.Marshal(handle)
+	require.NoError(t, err)
+
+	// The new cluster has not sent any requests, so must discover the endpoint of the handle.
+	other, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(other)
+
+	loaded, err := other.LoadQueryHandle(data)
+	require.NoError(t, err)
+
+	res, err := loaded.FetchResults(ctx)
+	require.NoError(t, err)
+
+	actualRows := CollectRows[int](t, res)
+	require.Len(t, actualRows, 10)
+	require.NoError(t, res.Err())
+
+	err = loaded.Discard(ctx)
+	require.NoError(t, err)
+}
+
+func TestStartQueryError(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err = cluster.StartQuery(ctx, "SELEC 123;")
+	require.ErrorIs(t, err, cbcolumnar.ErrQuery)
+}
+
+func TestLoadQueryHandleInvalid(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	_, err = cluster.LoadQueryHandle([]byte("{}"))
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
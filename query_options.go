@@ -11,6 +11,10 @@ const (
 )
 
 // QueryOptions is the set of options available to an Analytics query.
+//
+// Note that the analytics service does not support prepared statements, every statement is compiled
+// by the server when it is executed. Statements which are executed repeatedly with different values
+// should use PositionalParameters or NamedParameters rather than building the statement text.
 type QueryOptions struct {
 	// Priority sets whether this query should be assigned as high priority by the analytics engine.
 	Priority *bool
@@ -32,6 +36,15 @@ type QueryOptions struct {
 
 	// Unmarshaler specifies the default unmarshaler to use for decoding rows from this query.
 	Unmarshaler Unmarshaler
+
+	// Profile specifies the level of profiling information the server should return for this query,
+	// which is exposed via QueryMetadata.Profile.
+	Profile *QueryProfileMode
+
+	// ClientContextID specifies the client context ID to send with the query, which can be used to
+	// identify the query on the server, for example when cancelling it using Cluster.CancelQuery.
+	// If not set then a random ID is generated.
+	ClientContextID string
 }
 
 // NewQueryOptions creates a new instance of QueryOptions.
@@ -44,6 +57,8 @@ func NewQueryOptions() *QueryOptions {
 		ScanConsistency:      nil,
 		Raw:                  nil,
 		Unmarshaler:          nil,
+		Profile:              nil,
+		ClientContextID:      "",
 	}
 }
 
@@ -95,3 +110,17 @@ func (opts *QueryOptions) SetUnmarshaler(unmarshaler Unmarshaler) *QueryOptions
 
 	return opts
 }
+
+// SetProfile sets the Profile field in QueryOptions.
+func (opts *QueryOptions) SetProfile(profile QueryProfileMode) *QueryOptions {
+	opts.Profile = &profile
+
+	return opts
+}
+
+// SetClientContextID sets the ClientContextID field in QueryOptions.
+func (opts *QueryOptions) SetClientContextID(clientContextID string) *QueryOptions {
+	opts.ClientContextID = clientContextID
+
+	return opts
+}
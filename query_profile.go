@@ -0,0 +1,176 @@
+package cbcolumnar
+
+import (
+	"encoding/json"
+)
+
+// QueryProfileMode specifies the level of profiling information the server should return for a query.
+type QueryProfileMode uint
+
+const (
+	// QueryProfileModeOff indicates that no profiling information should be returned.
+	QueryProfileModeOff QueryProfileMode = iota + 1
+
+	// QueryProfileModeCounts indicates that operator counts should be returned.
+	QueryProfileModeCounts
+
+	// QueryProfileModeTimings indicates that operator counts and timings should be returned.
+	QueryProfileModeTimings
+)
+
+// QueryPlan represents a single operator within the plan of a query, as returned by ExplainQuery.
+type QueryPlan struct {
+	Operator         string
+	OperatorID       string
+	PhysicalOperator string
+	ExecutionMode    string
+	DataSource       string
+	Inputs           []QueryPlan
+
+	// Raw contains the raw JSON of this operator as returned by the server, including any fields
+	// which are not exposed by QueryPlan.
+	Raw json.RawMessage
+}
+
+// FullScans returns all operators within the plan which perform a full scan of a collection.
+func (p *QueryPlan) FullScans() []*QueryPlan {
+	var scans []*QueryPlan
+
+	if p.Operator == "data-scan" {
+		scans = append(scans, p)
+	}
+
+	for i := range p.Inputs {
+		scans = append(scans, p.Inputs[i].FullScans()...)
+	}
+
+	return scans
+}
+
+// QueryProfile contains the profiling information returned by the server when a QueryProfileMode
+// other than QueryProfileModeOff was specified.
+type QueryProfile struct {
+	JobID   string
+	Joblets []QueryProfileJoblet
+
+	// Raw contains the raw JSON of the profile as returned by the server, including any fields
+	// which are not exposed by QueryProfile.
+	Raw json.RawMessage
+}
+
+// QueryProfileJoblet contains the profiling information for the part of a query executed on a single node.
+type QueryProfileJoblet struct {
+	NodeID string
+	Tasks  []QueryProfileTask
+}
+
+// QueryProfileTask contains the profiling information for a single task executed as a part of a query.
+type QueryProfileTask struct {
+	ActivityID string
+	Partition  int
+	Counters   []QueryProfileCounter
+}
+
+// QueryProfileCounter contains a single counter for a task, such as the time spent in an operator.
+type QueryProfileCounter struct {
+	Name  string
+	Value float64
+}
+
+type jsonQueryPlan struct {
+	Operator         string            `json:"operator"`
+	OperatorID       string            `json:"operatorId"`
+	PhysicalOperator string            `json:"physical-operator"`
+	ExecutionMode    string            `json:"execution-mode"`
+	DataSource       string            `json:"data-source"`
+	Inputs           []json.RawMessage `json:"inputs"`
+}
+
+type jsonQueryProfile struct {
+	JobID   string                   `json:"job-id"`
+	Joblets []jsonQueryProfileJoblet `json:"joblets"`
+}
+
+type jsonQueryProfileJoblet struct {
+	NodeID string                 `json:"node-id"`
+	Tasks  []jsonQueryProfileTask `json:"tasks"`
+}
+
+type jsonQueryProfileTask struct {
+	ActivityID string                    `json:"activity-id"`
+	Partition  int                       `json:"partition"`
+	Counters   []jsonQueryProfileCounter `json:"counters"`
+}
+
+type jsonQueryProfileCounter struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"time"`
+}
+
+func (p *QueryPlan) fromData(data json.RawMessage) error {
+	var jsonPlan jsonQueryPlan
+
+	err := json.Unmarshal(data, &jsonPlan)
+	if err != nil {
+		return unmarshalError{
+			Reason: err.Error(),
+		}
+	}
+
+	inputs := make([]QueryPlan, len(jsonPlan.Inputs))
+	for i, input := range jsonPlan.Inputs {
+		err := inputs[i].fromData(input)
+		if err != nil {
+			return err
+		}
+	}
+
+	p.Operator = jsonPlan.Operator
+	p.OperatorID = jsonPlan.OperatorID
+	p.PhysicalOperator = jsonPlan.PhysicalOperator
+	p.ExecutionMode = jsonPlan.ExecutionMode
+	p.DataSource = jsonPlan.DataSource
+	p.Inputs = inputs
+	p.Raw = data
+
+	return nil
+}
+
+func (p *QueryProfile) fromData(data json.RawMessage) error {
+	var jsonProfile jsonQueryProfile
+
+	err := json.Unmarshal(data, &jsonProfile)
+	if err != nil {
+		return unmarshalError{
+			Reason: err.Error(),
+		}
+	}
+
+	joblets := make([]QueryProfileJoblet, len(jsonProfile.Joblets))
+	for i, jsonJoblet := range jsonProfile.Joblets {
+		tasks := make([]QueryProfileTask, len(jsonJoblet.Tasks))
+		for j, jsonTask := range jsonJoblet.Tasks {
+			counters := make([]QueryProfileCounter, len(jsonTask.Counters))
+			for k, jsonCounter := range jsonTask.Counters {
+				counters[k] = QueryProfileCounter(jsonCounter)
+			}
+
+			tasks[j] = QueryProfileTask{
+				ActivityID: jsonTask.ActivityID,
+				Partition:  jsonTask.Partition,
+				Counters:   counters,
+			}
+		}
+
+		joblets[i] = QueryProfileJoblet{
+			NodeID: jsonJoblet.NodeID,
+			Tasks:  tasks,
+		}
+	}
+
+	p.JobID = jsonProfile.JobID
+	p.Joblets = joblets
+	p.Raw = data
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package cbcolumnar
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryPlanFullScans(t *testing.T) {
+	raw := json.RawMessage(`{
+		"operator": "distribute-result",
+		"operatorId": "1.1",
+		"physical-operator": "DISTRIBUTE_RESULT",
+		"execution-mode": "PARTITIONED",
+		"inputs": [{
+			"operator": "project",
+			"operatorId": "1.2",
+			"inputs": [{
+				"operator": "data-scan",
+				"operatorId": "1.3",
+				"data-source": "default.test.airline"
+			}]
+		}]
+	}`)
+
+	var plan QueryPlan
+
+	err := plan.fromData(raw)
+	require.NoError(t, err)
+
+	assert.Equal(t, "distribute-result", plan.Operator)
+	assert.Equal(t, "1.1", plan.OperatorID)
+	assert.Equal(t, "DISTRIBUTE_RESULT", plan.PhysicalOperator)
+	assert.Equal(t, "PARTITIONED", plan.ExecutionMode)
+	require.Len(t, plan.Inputs, 1)
+
+	scans := plan.FullScans()
+	require.Len(t, scans, 1)
+
+	assert.Equal(t, "default.test.airline", scans[0].DataSource)
+}
+
+func TestQueryProfileFromData(t *testing.T) {
+	raw := json.RawMessage(`{
+		"job-id": "JID:0.4",
+		"joblets": [{
+			"node-id": "node1",
+			"tasks": [{
+				"activity-id": "ANID:ODID:1:0",
+				"partition": 1,
+				"counters": [{"name": "scan", "time": 0.25}]
+			}]
+		}]
+	}`)
+
+	var profile QueryProfile
+
+	err := profile.fromData(raw)
+	require.NoError(t, err)
+
+	assert.Equal(t, "JID:0.4", profile.JobID)
+	require.Len(t, profile.Joblets, 1)
+	assert.Equal(t, "node1", profile.Joblets[0].NodeID)
+	require.Len(t, profile.Joblets[0].Tasks, 1)
+	assert.Equal(t, 1, profile.Joblets[0].Tasks[0].Partition)
+	assert.Equal(t, []QueryProfileCounter{{Name: "scan", Value: 0.25}}, profile.Joblets[0].Tasks[0].Counters)
+}
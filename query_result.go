@@ -1,6 +1,8 @@
 package cbcolumnar
 
 import (
+	"encoding/json"
+	"iter"
 	"time"
 )
 
@@ -11,6 +13,10 @@ type QueryMetrics struct {
 	ResultCount      uint64
 	ResultSize       uint64
 	ProcessedObjects uint64
+	MutationCount    uint64
+	SortCount        uint64
+	ErrorCount       uint64
+	WarningCount     uint64
 }
 
 // QueryWarning encapsulates any warnings returned by a query.
@@ -19,11 +25,64 @@ type QueryWarning struct {
 	Message string
 }
 
+// QueryStatus indicates the state of a query, as reported by the server.
+type QueryStatus string
+
+const (
+	// QueryStatusQueued indicates that the query is waiting to be executed.
+	QueryStatusQueued QueryStatus = "queued"
+
+	// QueryStatusRunning indicates that the query is currently executing.
+	QueryStatusRunning QueryStatus = "running"
+
+	// QueryStatusSuccess indicates that the query completed successfully and results are available.
+	QueryStatusSuccess QueryStatus = "success"
+
+	// QueryStatusFailed indicates that the query failed.
+	QueryStatusFailed QueryStatus = "failed"
+
+	// QueryStatusTimeout indicates that the query exceeded the server query timeout.
+	QueryStatusTimeout QueryStatus = "timeout"
+
+	// QueryStatusFatal indicates that the query failed due to a fatal error on the server.
+	QueryStatusFatal QueryStatus = "fatal"
+)
+
+// QuerySignature describes the shape of the rows returned by a query, as reported by the server.
+type QuerySignature struct {
+	// Columns maps the name of each column in the rows to its type, where the server provided them.
+	// A signature of {"*": "*"} indicates that the rows may contain any columns.
+	Columns map[string]string
+
+	// Raw contains the raw JSON signature as returned by the server.
+	Raw json.RawMessage
+}
+
 // QueryMetadata provides access to the meta-data properties of a query result.
 type QueryMetadata struct {
-	RequestID string
-	Metrics   QueryMetrics
-	Warnings  []QueryWarning
+	RequestID       string
+	ClientContextID string
+	Metrics         QueryMetrics
+	Warnings        []QueryWarning
+
+	// Profile contains the profiling information returned by the server, if QueryOptions.Profile was set.
+	Profile *QueryProfile
+
+	// Status is the final status of the query as reported by the server.
+	Status QueryStatus
+
+	// Signature describes the shape of the rows returned by the query, if the server provided one. As the
+	// meta-data is only available once all rows have been read, the signature cannot be used to determine the
+	// columns ahead of decoding the rows.
+	Signature *QuerySignature
+
+	// Raw contains the raw JSON meta-data as returned by the server, including any fields which are
+	// not exposed by QueryMetadata.
+	Raw json.RawMessage
+
+	// Truncated indicates that the result was closed before all rows had been read.
+	// When set, the server did not send any meta-data and so the other fields are left empty.
+	Truncated bool
 }
 
 // QueryResult allows access to the results of a query.
@@ -31,12 +90,22 @@ type QueryResult struct {
 	reader analyticsRowReader
 
 	unmarshaler Unmarshaler
+	logger      *clusterLogger
+
+	finished bool
+	closed   bool
 }
 
 // NextRow returns the next row in the result set, or nil if there are no more rows.
 func (r *QueryResult) NextRow() *QueryResultRow {
+	if r.closed || r.finished {
+		return nil
+	}
+
 	rowBytes := r.reader.NextRow()
 	if rowBytes == nil {
+		r.finished = true
+
 		return nil
 	}
 
@@ -48,7 +117,7 @@ func (r *QueryResult) NextRow() *QueryResultRow {
 
 // Err returns any errors that have occurred on the stream.
 func (r *QueryResult) Err() error {
-	if r.reader == nil {
+	if r.reader == nil || r.closed {
 		return ErrClosed
 	}
 
@@ -63,7 +132,33 @@ func (r *QueryResult) Err() error {
 // MetaData returns any meta-data that was available from this query.  Note that
 // the meta-data will only be available once the object has been closed (either
 // implicitly or explicitly).
+// If the result was closed before all rows were read then the returned meta-data
+// will be marked as Truncated.
 func (r *QueryResult) MetaData() (*QueryMetadata, error) {
+	if r.closed && !r.finished {
+		return &QueryMetadata{
+			RequestID:       "",
+			ClientContextID: "",
+			Metrics: QueryMetrics{
+				ElapsedTime:      0,
+				ExecutionTime:    0,
+				ResultCount:      0,
+				ResultSize:       0,
+				ProcessedObjects: 0,
+				MutationCount:    0,
+				SortCount:        0,
+				ErrorCount:       0,
+				WarningCount:     0,
+			},
+			Warnings:  nil,
+			Profile:   nil,
+			Status:    "",
+			Signature: nil,
+			Raw:       nil,
+			Truncated: true,
+		}, nil
+	}
+
 	meta, err := r.reader.MetaData()
 	if err != nil {
 		return nil, err
@@ -72,6 +167,51 @@ func (r *QueryResult) MetaData() (*QueryMetadata, error) {
 	return meta, nil
 }
 
+// Close immediately shuts down the result stream, releasing the underlying connection.
+// Any rows which have not yet been read are discarded, subsequent calls to NextRow
+// will return nil and Err will return ErrClosed.
+// It is not necessary to call Close once all rows have been read.
+func (r *QueryResult) Close() error {
+	if r.closed {
+		return nil
+	}
+
+	r.closed = true
+
+	if r.finished {
+		return nil
+	}
+
+	return r.reader.Close()
+}
+
+// Rows returns an iterator over the rows in the result set.
+// If iteration is stopped early then the result is closed, discarding any remaining rows.
+// Any error which occurred on the stream is yielded as the final value of the iterator.
+func (r *QueryResult) Rows() iter.Seq2[*QueryResultRow, error] {
+	return func(yield func(*QueryResultRow, error) bool) {
+		for row := r.NextRow(); row != nil; row = r.NextRow() {
+			if !yield(row, nil) {
+				r.closeFromIterator()
+
+				return
+			}
+		}
+
+		err := r.Err()
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+func (r *QueryResult) closeFromIterator() {
+	err := r.Close()
+	if err != nil {
+		r.logger.Debugf("Failed to close query result after iteration stopped: %s", err)
+	}
+}
+
 // QueryResultRow encapsulates a single row of a query result.
 type QueryResultRow struct {
 	rowBytes []byte
@@ -121,6 +261,47 @@ func BufferQueryResult[T any](result *QueryResult) ([]T, *QueryMetadata, error)
 	return buffered, meta, nil
 }
 
+// QueryRowsAs returns an iterator over the rows in the result set, unmarshalling each row into a value of type T.
+// If iteration is stopped early then the result is closed, discarding any remaining rows.
+// Any error which occurred on the stream is yielded as the final value of the iterator.
+func QueryRowsAs[T any](result *QueryResult) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		if result == nil {
+			yield(zero, invalidArgumentError{
+				ArgumentName: "result",
+				Reason:       "result cannot be nil",
+			})
+
+			return
+		}
+
+		for row, err := range result.Rows() {
+			if err != nil {
+				yield(zero, err)
+
+				return
+			}
+
+			var contentAs T
+
+			err = row.ContentAs(&contentAs)
+			if err != nil {
+				if !yield(zero, err) {
+					return
+				}
+
+				continue
+			}
+
+			if !yield(contentAs, nil) {
+				return
+			}
+		}
+	}
+}
+
 type analyticsRowReader interface {
 	NextRow() []byte
 	MetaData() (*QueryMetadata, error)
@@ -1,6 +1,7 @@
 package cbcolumnar
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -27,39 +28,80 @@ type jsonAnalyticsResponse struct {
 	Status          string                 `json:"status"`
 	Warnings        []jsonAnalyticsWarning `json:"warnings"`
 	Metrics         jsonAnalyticsMetrics   `json:"metrics"`
-	Signature       interface{}            `json:"signature"`
+	Signature       json.RawMessage        `json:"signature"`
 	Handle          string                 `json:"handle,omitempty"`
+	Profile         json.RawMessage        `json:"profile,omitempty"`
 }
 
-func (meta *QueryMetadata) fromData(data jsonAnalyticsResponse) {
+type jsonAnalyticsHandleStatus struct {
+	RequestID string               `json:"requestID"`
+	Status    string               `json:"status"`
+	Handle    string               `json:"handle,omitempty"`
+	Metrics   jsonAnalyticsMetrics `json:"metrics"`
+}
+
+func (meta *QueryMetadata) fromData(data jsonAnalyticsResponse, logger *clusterLogger) {
 	metrics := QueryMetrics{
 		ElapsedTime:      0,
 		ExecutionTime:    0,
 		ResultCount:      0,
 		ResultSize:       0,
 		ProcessedObjects: 0,
+		MutationCount:    0,
+		SortCount:        0,
+		ErrorCount:       0,
+		WarningCount:     0,
 	}
-	metrics.fromData(data.Metrics)
+	metrics.fromData(data.Metrics, logger)
 
 	warnings := make([]QueryWarning, len(data.Warnings))
 	for wIdx, jsonWarning := range data.Warnings {
 		warnings[wIdx].fromData(jsonWarning)
 	}
 
+	var profile *QueryProfile
+
+	if len(data.Profile) > 0 {
+		profile = &QueryProfile{
+			JobID:   "",
+			Joblets: nil,
+			Raw:     nil,
+		}
+
+		err := profile.fromData(data.Profile)
+		if err != nil {
+			logger.Debugf("Failed to parse query profile: %s", err)
+		}
+	}
+
+	var signature *QuerySignature
+
+	if len(data.Signature) > 0 && string(data.Signature) != "null" {
+		signature = &QuerySignature{
+			Columns: nil,
+			Raw:     nil,
+		}
+		signature.fromData(data.Signature, logger)
+	}
+
 	meta.RequestID = data.RequestID
+	meta.ClientContextID = data.ClientContextID
 	meta.Metrics = metrics
 	meta.Warnings = warnings
+	meta.Profile = profile
+	meta.Status = QueryStatus(data.Status)
+	meta.Signature = signature
 }
 
-func (metrics *QueryMetrics) fromData(data jsonAnalyticsMetrics) {
+func (metrics *QueryMetrics) fromData(data jsonAnalyticsMetrics, logger *clusterLogger) {
 	elapsedTime, err := time.ParseDuration(data.ElapsedTime)
 	if err != nil {
-		logDebugf("Failed to parse query metrics elapsed time: %s", err)
+		logger.Debugf("Failed to parse query metrics elapsed time: %s", err)
 	}
 
 	executionTime, err := time.ParseDuration(data.ExecutionTime)
 	if err != nil {
-		logDebugf("Failed to parse query metrics execution time: %s", err)
+		logger.Debugf("Failed to parse query metrics execution time: %s", err)
 	}
 
 	metrics.ElapsedTime = elapsedTime
@@ -67,9 +109,47 @@ func (metrics *QueryMetrics) fromData(data jsonAnalyticsMetrics) {
 	metrics.ResultCount = data.ResultCount
 	metrics.ResultSize = data.ResultSize
 	metrics.ProcessedObjects = data.ProcessedObjects
+	metrics.MutationCount = data.MutationCount
+	metrics.SortCount = data.SortCount
+	metrics.ErrorCount = data.ErrorCount
+	metrics.WarningCount = data.WarningCount
 }
 
 func (warning *QueryWarning) fromData(data jsonAnalyticsWarning) {
 	warning.Code = data.Code
 	warning.Message = data.Message
 }
+
+func (status *QueryHandleStatus) fromData(data jsonAnalyticsHandleStatus, logger *clusterLogger) {
+	metrics := QueryMetrics{
+		ElapsedTime:      0,
+		ExecutionTime:    0,
+		ResultCount:      0,
+		ResultSize:       0,
+		ProcessedObjects: 0,
+		MutationCount:    0,
+		SortCount:        0,
+		ErrorCount:       0,
+		WarningCount:     0,
+	}
+
+	if data.Metrics.ElapsedTime != "" {
+		metrics.fromData(data.Metrics, logger)
+	}
+
+	status.Status = QueryStatus(data.Status)
+	status.RequestID = data.RequestID
+	status.Metrics = metrics
+}
+
+func (signature *QuerySignature) fromData(data json.RawMessage, logger *clusterLogger) {
+	var columns map[string]string
+
+	err := json.Unmarshal(data, &columns)
+	if err != nil {
+		logger.Debugf("Failed to parse query signature columns: %s", err)
+	}
+
+	signature.Columns = columns
+	signature.Raw = data
+}
@@ -0,0 +1,65 @@
+package cbcolumnar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQueryMetadata(t *testing.T) {
+	raw := []byte(`{
+		"requestID": "94c7f89f-924a-4c9f-8ab4-7e3fd4c3f5e3",
+		"clientContextID": "my-context",
+		"signature": {"*": "*"},
+		"status": "success",
+		"metrics": {
+			"elapsedTime": "20.5ms",
+			"executionTime": "19.2ms",
+			"resultCount": 10,
+			"resultSize": 100,
+			"mutationCount": 1,
+			"sortCount": 2,
+			"errorCount": 3,
+			"warningCount": 4,
+			"processedObjects": 5
+		}
+	}`)
+
+	meta, err := parseQueryMetadata(raw, newClusterLogger("test", nil, nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, "94c7f89f-924a-4c9f-8ab4-7e3fd4c3f5e3", meta.RequestID)
+	assert.Equal(t, "my-context", meta.ClientContextID)
+	assert.Equal(t, QueryStatusSuccess, meta.Status)
+	assert.Equal(t, QueryMetrics{
+		ElapsedTime:      20500 * time.Microsecond,
+		ExecutionTime:    19200 * time.Microsecond,
+		ResultCount:      10,
+		ResultSize:       100,
+		ProcessedObjects: 5,
+		MutationCount:    1,
+		SortCount:        2,
+		ErrorCount:       3,
+		WarningCount:     4,
+	}, meta.Metrics)
+
+	require.NotNil(t, meta.Signature)
+	assert.Equal(t, map[string]string{"*": "*"}, meta.Signature.Columns)
+	assert.JSONEq(t, `{"*": "*"}`, string(meta.Signature.Raw))
+	assert.Equal(t, raw, []byte(meta.Raw))
+}
+
+func TestParseQueryMetadataLogsToClusterLogger(t *testing.T) {
+	formatter := &formatLogger{messages: nil}
+
+	meta, err := parseQueryMetadata([]byte(`{"metrics":{"elapsedTime":"bad","executionTime":"1ms"}}`),
+		newClusterLogger("cluster-1", formatter, nil))
+	require.NoError(t, err)
+
+	assert.Zero(t, meta.Metrics.ElapsedTime)
+	require.Len(t, formatter.messages, 1)
+	assert.Contains(t, formatter.messages[0], "Failed to parse query metrics elapsed time")
+	assert.Contains(t, formatter.messages[0], "cluster_id=cluster-1")
+}
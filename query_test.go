@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
 	cbcolumnar "github.com/couchbase/gocbcolumnar"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -20,7 +22,7 @@ func TestBasicQuery(t *testing.T) {
 		assert.NoError(t, err)
 	}(cluster)
 
-	ExecuteQueryAgainst(t, []Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable Queryable) {
+	ExecuteQueryAgainst(t, []cbcolumnar.Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable cbcolumnar.Queryable) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
@@ -52,7 +54,7 @@ func TestBasicBufferedQuery(t *testing.T) {
 		assert.NoError(t, err)
 	}(cluster)
 
-	ExecuteQueryAgainst(t, []Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable Queryable) {
+	ExecuteQueryAgainst(t, []cbcolumnar.Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable cbcolumnar.Queryable) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
@@ -70,6 +72,93 @@ func TestBasicBufferedQuery(t *testing.T) {
 	})
 }
 
+func TestQueryResultClose(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ExecuteQueryAgainst(t, []cbcolumnar.Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable cbcolumnar.Queryable) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		res, err := queryable.ExecuteQuery(ctx, "FROM RANGE(0, 9999) AS i SELECT RAW i")
+		require.NoError(tt, err)
+
+		row := res.NextRow()
+		require.NotNil(tt, row)
+
+		err = res.Close()
+		require.NoError(tt, err)
+
+		assert.Nil(tt, res.NextRow())
+		require.ErrorIs(tt, res.Err(), cbcolumnar.ErrClosed)
+
+		meta, err := res.MetaData()
+		require.NoError(tt, err)
+
+		assert.True(tt, meta.Truncated)
+
+		err = res.Close()
+		require.NoError(tt, err)
+	})
+}
+
+func TestQueryRowsIterator(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr, cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password), DefaultOptions())
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ExecuteQueryAgainst(t, []cbcolumnar.Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable cbcolumnar.Queryable) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		res, err := queryable.ExecuteQuery(ctx, "FROM RANGE(0, 99) AS i SELECT RAW i")
+		require.NoError(tt, err)
+
+		var actualRows []int
+
+		for row, err := range cbcolumnar.QueryRowsAs[int](res) {
+			require.NoError(tt, err)
+
+			actualRows = append(actualRows, row)
+		}
+
+		require.Len(tt, actualRows, 100)
+
+		for i := 0; i < 100; i++ {
+			require.Equal(tt, i, actualRows[i])
+		}
+
+		meta, err := res.MetaData()
+		require.NoError(tt, err)
+
+		assertMeta(tt, meta, 100)
+
+		res, err = queryable.ExecuteQuery(ctx, "FROM RANGE(0, 9999) AS i SELECT RAW i")
+		require.NoError(tt, err)
+
+		for row, err := range res.Rows() {
+			require.NoError(tt, err)
+			require.NotNil(tt, row)
+
+			break
+		}
+
+		require.ErrorIs(tt, res.Err(), cbcolumnar.ErrClosed)
+
+		meta, err = res.MetaData()
+		require.NoError(tt, err)
+
+		assert.True(tt, meta.Truncated)
+	})
+}
+
 func TestDispatchTimeout(t *testing.T) {
 	// We're purposely using an invalid hostname so we need to suppress warnings.
 	globalTestLogger.SuppressWarnings(true)
@@ -85,7 +174,7 @@ func TestDispatchTimeout(t *testing.T) {
 		return cluster
 	}
 
-	runTest := func(ctx context.Context, tt *testing.T, queryable Queryable, expectedErr error) {
+	runTest := func(ctx context.Context, tt *testing.T, queryable cbcolumnar.Queryable, expectedErr error) {
 		_, err := queryable.ExecuteQuery(ctx, "SELECT sleep('foo', 5000);")
 		require.ErrorIs(tt, err, expectedErr)
 
@@ -181,7 +270,7 @@ func TestOperationTimeout(t *testing.T) {
 	}(cluster)
 
 	t.Run("Context Deadline", func(tt *testing.T) {
-		ExecuteQueryAgainst(tt, []Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(ttt *testing.T, queryable Queryable) {
+		ExecuteQueryAgainst(tt, []cbcolumnar.Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(ttt *testing.T, queryable cbcolumnar.Queryable) {
 			ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 			defer cancel()
 
@@ -197,7 +286,7 @@ func TestOperationTimeout(t *testing.T) {
 	})
 
 	t.Run("Context Cancel", func(tt *testing.T) {
-		ExecuteQueryAgainst(tt, []Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(ttt *testing.T, queryable Queryable) {
+		ExecuteQueryAgainst(tt, []cbcolumnar.Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(ttt *testing.T, queryable cbcolumnar.Queryable) {
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			go func() {
 				time.Sleep(1 * time.Second)
@@ -226,7 +315,7 @@ func TestOperationTimeout(t *testing.T) {
 			assert.NoError(tt, err)
 		}(cluster)
 
-		ExecuteQueryAgainst(tt, []Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(ttt *testing.T, queryable Queryable) {
+		ExecuteQueryAgainst(tt, []cbcolumnar.Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(ttt *testing.T, queryable cbcolumnar.Queryable) {
 			ctx := context.Background()
 
 			_, err := queryable.ExecuteQuery(ctx, "SELECT sleep('foo', 5000);")
@@ -241,6 +330,200 @@ func TestOperationTimeout(t *testing.T) {
 	})
 }
 
+func TestCancelQuery(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr,
+		cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password),
+		DefaultOptions(),
+	)
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ExecuteQueryAgainst(t, []cbcolumnar.Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable cbcolumnar.Queryable) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		clientContextID := uuid.NewString()
+
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := queryable.ExecuteQuery(ctx, "SELECT sleep('foo', 20000);",
+				cbcolumnar.NewQueryOptions().SetClientContextID(clientContextID))
+			errCh <- err
+		}()
+
+		require.Eventually(tt, func() bool {
+			return cluster.CancelQuery(ctx, clientContextID) == nil
+		}, 10*time.Second, 100*time.Millisecond)
+
+		err := <-errCh
+		require.Error(tt, err)
+	})
+}
+
+func TestQueryClientContextID(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr,
+		cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password),
+		DefaultOptions(),
+	)
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ExecuteQueryAgainst(t, []cbcolumnar.Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable cbcolumnar.Queryable) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		clientContextID := uuid.NewString()
+
+		res, err := queryable.ExecuteQuery(ctx, "SELECT 1;", cbcolumnar.NewQueryOptions().SetClientContextID(clientContextID))
+		require.NoError(tt, err)
+
+		_, meta, err := cbcolumnar.BufferQueryResult[interface{}](res)
+		require.NoError(tt, err)
+
+		assert.Equal(tt, clientContextID, meta.ClientContextID)
+	})
+}
+
+func TestCancelQueryNotFound(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr,
+		cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password),
+		DefaultOptions(),
+	)
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err = cluster.CancelQuery(ctx, uuid.NewString())
+	require.ErrorIs(t, err, cbcolumnar.ErrColumnar)
+
+	err = cluster.CancelQuery(ctx, "")
+	require.ErrorIs(t, err, cbcolumnar.ErrInvalidArgument)
+}
+
+func TestExplainQuery(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr,
+		cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password),
+		DefaultOptions(),
+	)
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	explainers := []cbcolumnar.Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}
+
+	for _, explainer := range explainers {
+		t.Run(reflect.TypeOf(explainer).Elem().String(), func(tt *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			plan, err := explainer.ExplainQuery(ctx, "FROM RANGE(0, 99) AS i SELECT RAW i")
+			require.NoError(tt, err)
+
+			assert.NotEmpty(tt, plan.Operator)
+			assert.NotEmpty(tt, plan.Raw)
+		})
+	}
+}
+
+func TestQueryProfile(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr,
+		cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password),
+		DefaultOptions(),
+	)
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ExecuteQueryAgainst(t, []cbcolumnar.Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable cbcolumnar.Queryable) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		res, err := queryable.ExecuteQuery(ctx, "FROM RANGE(0, 99) AS i SELECT RAW i",
+			cbcolumnar.NewQueryOptions().SetProfile(cbcolumnar.QueryProfileModeTimings))
+		require.NoError(tt, err)
+
+		_, meta, err := cbcolumnar.BufferQueryResult[int](res)
+		require.NoError(tt, err)
+
+		require.NotNil(tt, meta.Profile)
+		assert.NotEmpty(tt, meta.Profile.Raw)
+	})
+}
+
+func TestExecuteQueryAs(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr,
+		cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password),
+		DefaultOptions(),
+	)
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ExecuteQueryAgainst(t, []cbcolumnar.Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable cbcolumnar.Queryable) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		rows, meta, err := cbcolumnar.ExecuteQueryAs[int](ctx, queryable, "FROM RANGE(0, 99) AS i SELECT RAW i")
+		require.NoError(tt, err)
+
+		require.Len(tt, rows, 100)
+
+		for i := 0; i < 100; i++ {
+			require.Equal(tt, i, rows[i])
+		}
+
+		assertMeta(tt, meta, 100)
+
+		_, _, err = cbcolumnar.ExecuteQueryAs[int](ctx, queryable, "SELEC 123;")
+		require.ErrorIs(tt, err, cbcolumnar.ErrQuery)
+	})
+}
+
+func TestExecuteQueryOne(t *testing.T) {
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr,
+		cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password),
+		DefaultOptions(),
+	)
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ExecuteQueryAgainst(t, []cbcolumnar.Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable cbcolumnar.Queryable) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		row, err := cbcolumnar.ExecuteQueryOne[int](ctx, queryable, "SELECT RAW 42")
+		require.NoError(tt, err)
+
+		assert.Equal(tt, 42, row)
+
+		_, err = cbcolumnar.ExecuteQueryOne[int](ctx, queryable, "FROM RANGE(0, 99) AS i WHERE i > 100 SELECT RAW i")
+		require.ErrorIs(tt, err, cbcolumnar.ErrNoRows)
+
+		_, err = cbcolumnar.ExecuteQueryOne[int](ctx, queryable, "FROM RANGE(0, 99) AS i SELECT RAW i")
+		require.ErrorIs(tt, err, cbcolumnar.ErrMultipleRows)
+	})
+}
+
 func TestQueryError(t *testing.T) {
 	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr,
 		cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password),
@@ -252,7 +535,7 @@ func TestQueryError(t *testing.T) {
 		assert.NoError(t, err)
 	}(cluster)
 
-	ExecuteQueryAgainst(t, []Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable Queryable) {
+	ExecuteQueryAgainst(t, []cbcolumnar.Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable cbcolumnar.Queryable) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
@@ -287,7 +570,7 @@ func TestUnmarshaler(t *testing.T) {
 		assert.NoError(t, err)
 	}(cluster)
 
-	ExecuteQueryAgainst(t, []Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable Queryable) {
+	ExecuteQueryAgainst(t, []cbcolumnar.Queryable{cluster, cluster.Database(TestOpts.Database).Scope(TestOpts.Scope)}, func(tt *testing.T, queryable cbcolumnar.Queryable) {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
@@ -303,6 +586,106 @@ func TestUnmarshaler(t *testing.T) {
 	})
 }
 
+func TestQueryDispatchSpan(t *testing.T) {
+	tracer := &recordingTracer{
+		lock:  sync.Mutex{},
+		spans: nil,
+	}
+
+	cluster, err := cbcolumnar.NewCluster(TestOpts.OriginalConnStr,
+		cbcolumnar.NewCredential(TestOpts.Username, TestOpts.Password),
+		DefaultOptions().SetTracer(tracer),
+	)
+	require.NoError(t, err)
+	defer func(cluster *cbcolumnar.Cluster) {
+		err := cluster.Close()
+		assert.NoError(t, err)
+	}(cluster)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	res, err := cluster.ExecuteQuery(ctx, "SELECT 1;")
+	require.NoError(t, err)
+
+	_, _, err = cbcolumnar.BufferQueryResult[interface{}](res)
+	require.NoError(t, err)
+
+	spans := tracer.Spans()
+	require.Len(t, spans, 2)
+
+	dispatch := spans[1]
+	assert.Equal(t, "dispatch_to_server", dispatch.name)
+	assert.Same(t, spans[0], dispatch.parent)
+	assert.NotEmpty(t, dispatch.Attribute("net.peer.name"))
+	assert.NotZero(t, dispatch.Attribute("net.peer.port"))
+	assert.Equal(t, 200, dispatch.Attribute("http.response.status_code"))
+	assert.True(t, dispatch.ended)
+}
+
+type recordingTracerSpanKey struct{}
+
+type recordingTracer struct {
+	lock  sync.Mutex
+	spans []*recordingSpan
+}
+
+func (t *recordingTracer) RequestSpan(ctx context.Context, name string) (context.Context, cbcolumnar.RequestSpan) {
+	parent, _ := ctx.Value(recordingTracerSpanKey{}).(*recordingSpan)
+
+	span := &recordingSpan{
+		lock:       sync.Mutex{},
+		name:       name,
+		parent:     parent,
+		attributes: make(map[string]interface{}),
+		ended:      false,
+	}
+
+	t.lock.Lock()
+	t.spans = append(t.spans, span)
+	t.lock.Unlock()
+
+	return context.WithValue(ctx, recordingTracerSpanKey{}, span), span
+}
+
+func (t *recordingTracer) Spans() []*recordingSpan {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return append([]*recordingSpan(nil), t.spans...)
+}
+
+type recordingSpan struct {
+	lock       sync.Mutex
+	name       string
+	parent     *recordingSpan
+	attributes map[string]interface{}
+	ended      bool
+}
+
+func (s *recordingSpan) SetAttribute(key string, value interface{}) {
+	s.lock.Lock()
+	s.attributes[key] = value
+	s.lock.Unlock()
+}
+
+func (s *recordingSpan) Attribute(key string) interface{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.attributes[key]
+}
+
+func (s *recordingSpan) AddEvent(_ string, _ time.Time) {}
+
+func (s *recordingSpan) RecordError(_ error) {}
+
+func (s *recordingSpan) End() {
+	s.lock.Lock()
+	s.ended = true
+	s.lock.Unlock()
+}
+
 type ErrorUnmarshaler struct {
 	Err error
 }
@@ -320,13 +703,15 @@ func assertMeta(t *testing.T, meta *cbcolumnar.QueryMetadata, resultCount uint64
 	assert.NotZero(t, meta.Metrics.ResultSize)
 	assert.Equal(t, resultCount, meta.Metrics.ResultCount)
 	assert.Zero(t, meta.Metrics.ProcessedObjects)
+	assert.Zero(t, meta.Metrics.ErrorCount)
+	assert.Zero(t, meta.Metrics.WarningCount)
+	assert.Equal(t, cbcolumnar.QueryStatusSuccess, meta.Status)
+	assert.NotEmpty(t, meta.ClientContextID)
+	assert.NotNil(t, meta.Signature)
+	assert.NotEmpty(t, meta.Raw)
 }
 
-type Queryable interface {
-	ExecuteQuery(ctx context.Context, statement string, opts ...*cbcolumnar.QueryOptions) (*cbcolumnar.QueryResult, error)
-}
-
-func ExecuteQueryAgainst(t *testing.T, queryables []Queryable, fn func(tt *testing.T, queryable Queryable)) {
+func ExecuteQueryAgainst(t *testing.T, queryables []cbcolumnar.Queryable, fn func(tt *testing.T, queryable cbcolumnar.Queryable)) {
 	for _, queryable := range queryables {
 		t.Run(reflect.TypeOf(queryable).Elem().String(), func(tt *testing.T) {
 			fn(tt, queryable)
@@ -0,0 +1,139 @@
+package cbcolumnar
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// srvLookupFunc looks up a DNS SRV record, see net.Resolver.LookupSRV.
+type srvLookupFunc func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+
+var errNoSrvTargets = errors.New("srv record has no targets")
+
+// lookupSrvAddresses resolves the SRV record for host to the addresses of its targets.
+func lookupSrvAddresses(ctx context.Context, lookup srvLookupFunc, host string) ([]address, error) {
+	_, records, err := lookup(ctx, "couchbases", "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, errNoSrvTargets
+	}
+
+	addrs := make([]address, len(records))
+	for i, record := range records {
+		addrs[i] = address{
+			Host: strings.TrimSuffix(record.Target, "."),
+			Port: int(record.Port),
+		}
+	}
+
+	return addrs, nil
+}
+
+// sortedAddresses returns a copy of addrs ordered by host and then port, so that address lists can be compared.
+func sortedAddresses(addrs []address) []address {
+	sorted := slices.Clone(addrs)
+	slices.SortFunc(sorted, func(a, b address) int {
+		if c := strings.Compare(a.Host, b.Host); c != 0 {
+			return c
+		}
+
+		return a.Port - b.Port
+	})
+
+	return sorted
+}
+
+// srvRefresher periodically re-resolves the SRV record for a host, calling update with the new addresses whenever
+// the targets change. If the record cannot be resolved then the existing addresses continue to be used.
+type srvRefresher struct {
+	host     string
+	interval time.Duration
+	timeout  time.Duration
+	lookup   srvLookupFunc
+	update   func([]address)
+	logger   *clusterLogger
+
+	addrs []address
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// newSrvRefresher starts re-resolving the SRV record for host at the interval, with addrs being the addresses
+// which are currently in use. The refresher must be closed once it is no longer needed.
+func newSrvRefresher(host string, interval, timeout time.Duration, lookup srvLookupFunc, addrs []address,
+	update func([]address), logger *clusterLogger) *srvRefresher {
+	r := &srvRefresher{
+		host:     host,
+		interval: interval,
+		timeout:  timeout,
+		lookup:   lookup,
+		update:   update,
+		logger:   logger,
+		addrs:    sortedAddresses(addrs),
+		stopCh:   make(chan struct{}),
+		wg:       sync.WaitGroup{},
+	}
+
+	r.wg.Add(1)
+
+	go r.loop()
+
+	return r
+}
+
+// Close stops re-resolving the SRV record.
+func (r *srvRefresher) Close() {
+	close(r.stopCh)
+	r.wg.Wait()
+}
+
+func (r *srvRefresher) loop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		r.refresh()
+	}
+}
+
+func (r *srvRefresher) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	addrs, err := lookupSrvAddresses(ctx, r.lookup, r.host)
+	if err != nil {
+		r.logger.WarnAttrs("Failed to refresh SRV record, continuing to use the existing addresses",
+			r.logger.SystemDataAttr(logAttrHost, r.host), r.logger.ErrAttr(err))
+
+		return
+	}
+
+	addrs = sortedAddresses(addrs)
+	if slices.Equal(addrs, r.addrs) {
+		return
+	}
+
+	r.logger.InfoAttrs("SRV record targets changed, updating the addresses", r.logger.SystemDataAttr(logAttrHost, r.host),
+		slog.Int(logAttrPreviousAddressCount, len(r.addrs)), slog.Int(logAttrAddressCount, len(addrs)))
+
+	r.addrs = addrs
+	r.update(addrs)
+}
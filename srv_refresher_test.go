@@ -0,0 +1,86 @@
+package cbcolumnar
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSrvRefresherUpdatesOnChange(t *testing.T) {
+	var lock sync.Mutex
+
+	records := []*net.SRV{
+		{Target: "node1.example.com.", Port: 11207, Priority: 0, Weight: 0},
+		{Target: "node2.example.com.", Port: 11207, Priority: 0, Weight: 0},
+	}
+	lookupErr := errors.New("lookup failed")
+	failLookup := false
+
+	lookup := func(_ context.Context, _, _, _ string) (string, []*net.SRV, error) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if failLookup {
+			return "", nil, lookupErr
+		}
+
+		return "", records, nil
+	}
+
+	updates := make(chan []address, 10)
+
+	refresher := newSrvRefresher("example.com", 10*time.Millisecond, time.Second, lookup, []address{
+		{Host: "node2.example.com", Port: 11207},
+		{Host: "node1.example.com", Port: 11207},
+	}, func(addrs []address) {
+		updates <- addrs
+	}, newClusterLogger("test", nil, nil))
+	defer refresher.Close()
+
+	// The targets match the initial addresses, so no update should occur.
+	select {
+	case addrs := <-updates:
+		t.Fatalf("unexpected update with unchanged targets: %v", addrs)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	lock.Lock()
+	failLookup = true
+	lock.Unlock()
+
+	// Failed lookups should not cause the existing addresses to be replaced.
+	select {
+	case addrs := <-updates:
+		t.Fatalf("unexpected update after failed lookup: %v", addrs)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	lock.Lock()
+	failLookup = false
+	records = []*net.SRV{
+		{Target: "node3.example.com.", Port: 11207, Priority: 0, Weight: 0},
+	}
+	lock.Unlock()
+
+	select {
+	case addrs := <-updates:
+		assert.Equal(t, []address{{Host: "node3.example.com", Port: 11207}}, addrs)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for update")
+	}
+}
+
+func TestLookupSrvAddressesNoTargets(t *testing.T) {
+	lookup := func(_ context.Context, _, _, _ string) (string, []*net.SRV, error) {
+		return "", nil, nil
+	}
+
+	_, err := lookupSrvAddresses(context.Background(), lookup, "example.com")
+	require.ErrorIs(t, err, errNoSrvTargets)
+}
@@ -0,0 +1,185 @@
+package cbcolumnar
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// thresholdLogItem describes a query which took longer than the threshold to complete.
+type thresholdLogItem struct {
+	Statement       string
+	ClientContextID string
+	Endpoint        string
+	TotalDuration   time.Duration
+	ServerDuration  time.Duration
+}
+
+type jsonThresholdLogItem struct {
+	OperationName    string `json:"operation_name"`
+	TotalDurationUs  int64  `json:"total_duration_us"`
+	ServerDurationUs int64  `json:"server_duration_us,omitempty"`
+	Statement        string `json:"statement,omitempty"`
+	ClientContextID  string `json:"client_context_id"`
+	Endpoint         string `json:"endpoint,omitempty"`
+}
+
+type jsonThresholdLogService struct {
+	TotalCount  uint64                 `json:"total_count"`
+	TopRequests []jsonThresholdLogItem `json:"top_requests"`
+}
+
+type jsonThresholdLogReport struct {
+	Analytics jsonThresholdLogService `json:"analytics"`
+}
+
+// thresholdLogger collects the queries which took longer than the threshold to complete, periodically logging
+// the slowest of them as a JSON summary.
+type thresholdLogger struct {
+	threshold  time.Duration
+	interval   time.Duration
+	sampleSize int
+	logger     *clusterLogger
+
+	lock       sync.Mutex
+	items      []thresholdLogItem
+	totalCount uint64
+
+	stopCh    chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// newThresholdLogger starts a threshold logger which logs the slowest sampleSize queries over the threshold at
+// the interval. The logger must be closed once it is no longer needed.
+func newThresholdLogger(threshold, interval time.Duration, sampleSize int, logger *clusterLogger) *thresholdLogger {
+	l := &thresholdLogger{
+		threshold:  threshold,
+		interval:   interval,
+		sampleSize: sampleSize,
+		logger:     logger,
+		lock:       sync.Mutex{},
+		items:      nil,
+		totalCount: 0,
+		stopCh:     make(chan struct{}),
+		closeOnce:  sync.Once{},
+		wg:         sync.WaitGroup{},
+	}
+
+	l.wg.Add(1)
+
+	go l.loop()
+
+	return l
+}
+
+// Record records the query if it took longer than the threshold to complete.
+func (l *thresholdLogger) Record(item thresholdLogItem) {
+	if item.TotalDuration < l.threshold {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.totalCount++
+
+	// The items are kept ordered from slowest to fastest, so only the fastest item needs to be checked.
+	if len(l.items) >= l.sampleSize {
+		if l.items[len(l.items)-1].TotalDuration >= item.TotalDuration {
+			return
+		}
+
+		l.items = l.items[:len(l.items)-1]
+	}
+
+	idx := sort.Search(len(l.items), func(i int) bool {
+		return l.items[i].TotalDuration < item.TotalDuration
+	})
+
+	l.items = slices.Insert(l.items, idx, item)
+}
+
+// Close stops the logger, logging any queries which have been recorded since the last summary. It is safe to call
+// Close more than once.
+func (l *thresholdLogger) Close() {
+	l.closeOnce.Do(func() {
+		close(l.stopCh)
+		l.wg.Wait()
+
+		l.emit()
+	})
+}
+
+func (l *thresholdLogger) loop() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		l.emit()
+	}
+}
+
+// emit logs the queries which have been recorded since the last summary, if any.
+func (l *thresholdLogger) emit() {
+	l.lock.Lock()
+	items := l.items
+	totalCount := l.totalCount
+	l.items = nil
+	l.totalCount = 0
+	l.lock.Unlock()
+
+	if totalCount == 0 {
+		return
+	}
+
+	report := jsonThresholdLogReport{
+		Analytics: jsonThresholdLogService{
+			TotalCount:  totalCount,
+			TopRequests: make([]jsonThresholdLogItem, len(items)),
+		},
+	}
+
+	redactionLevel := l.logger.RedactionLevel()
+
+	for i, item := range items {
+		// The statement is redacted in the same way as on query spans, and so is omitted when the redaction
+		// level is full.
+		statement, _ := traceStatement(item.Statement, redactionLevel)
+
+		report.Analytics.TopRequests[i] = jsonThresholdLogItem{
+			OperationName:    spanNameQuery,
+			TotalDurationUs:  item.TotalDuration.Microseconds(),
+			ServerDurationUs: item.ServerDuration.Microseconds(),
+			Statement:        statement,
+			ClientContextID:  item.ClientContextID,
+			Endpoint:         item.Endpoint,
+		}
+	}
+
+	// HTML escaping is disabled so that the redaction tags are logged as-is.
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	err := enc.Encode(report)
+	if err != nil {
+		l.logger.Debugf("Failed to marshal threshold log report: %s", err)
+
+		return
+	}
+
+	l.logger.Infof("Threshold Log: %s", bytes.TrimSpace(buf.Bytes()))
+}
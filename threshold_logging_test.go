@@ -0,0 +1,38 @@
+package cbcolumnar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThresholdLoggerRecord(t *testing.T) {
+	logger := newThresholdLogger(100*time.Millisecond, time.Hour, 2, newClusterLogger("test", nil, nil))
+	defer logger.Close()
+
+	record := func(id string, duration time.Duration) {
+		logger.Record(thresholdLogItem{
+			Statement:       "SELECT 1",
+			ClientContextID: id,
+			Endpoint:        "",
+			TotalDuration:   duration,
+			ServerDuration:  0,
+		})
+	}
+
+	record("fast", 50*time.Millisecond)
+	record("slow", 200*time.Millisecond)
+	record("slowest", 300*time.Millisecond)
+	record("slower", 250*time.Millisecond)
+
+	logger.lock.Lock()
+	defer logger.lock.Unlock()
+
+	// The query under the threshold is ignored and only the slowest sample size queries are kept.
+	assert.Equal(t, uint64(3), logger.totalCount)
+	require.Len(t, logger.items, 2)
+	assert.Equal(t, "slowest", logger.items[0].ClientContextID)
+	assert.Equal(t, "slower", logger.items[1].ClientContextID)
+}
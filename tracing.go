@@ -0,0 +1,86 @@
+package cbcolumnar
+
+import (
+	"context"
+	"time"
+)
+
+// RequestTracer creates the spans which describe the operations performed by the SDK, allowing queries to be
+// included in distributed traces. See the cbcolumnarotel package for an OpenTelemetry implementation.
+type RequestTracer interface {
+	// RequestSpan starts a new span with the given name as a child of the span contained within ctx, if any,
+	// returning a context containing the new span.
+	RequestSpan(ctx context.Context, name string) (context.Context, RequestSpan)
+}
+
+// RequestSpan is a single operation within a trace, created by a RequestTracer.
+type RequestSpan interface {
+	// SetAttribute sets an attribute on the span. The value is one of string, bool, int, int64, uint64,
+	// float64 or time.Duration.
+	SetAttribute(key string, value interface{})
+
+	// AddEvent records an event which occurred at the timestamp during the span.
+	AddEvent(name string, timestamp time.Time)
+
+	// RecordError records that the operation described by the span failed with err.
+	RecordError(err error)
+
+	// End completes the span.
+	End()
+}
+
+// The names of the spans created by the SDK.
+const (
+	spanNameQuery            = "query"
+	spanNameDispatchToServer = "dispatch_to_server"
+)
+
+// The attributes set on the spans created by the SDK.
+const (
+	spanAttribDBSystem        = "db.system"
+	spanAttribService         = "db.couchbase.service"
+	spanAttribStatement       = "db.statement"
+	spanAttribDatabase        = "db.name"
+	spanAttribScope           = "db.couchbase.scope"
+	spanAttribClientContextID = "db.couchbase.client_context_id"
+	spanAttribRowCount        = "db.couchbase.row_count"
+	spanAttribServerDuration  = "db.couchbase.server_duration"
+	spanAttribNetPeerName     = "net.peer.name"
+	spanAttribNetPeerPort     = "net.peer.port"
+	spanAttribHTTPStatusCode  = "http.response.status_code"
+)
+
+const (
+	spanDBSystemCouchbase = "couchbase"
+	spanServiceAnalytics  = "analytics"
+)
+
+// noopTracer is the RequestTracer used when no tracer is configured.
+type noopTracer struct{}
+
+func (t noopTracer) RequestSpan(ctx context.Context, _ string) (context.Context, RequestSpan) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (s noopSpan) SetAttribute(_ string, _ interface{}) {}
+
+func (s noopSpan) AddEvent(_ string, _ time.Time) {}
+
+func (s noopSpan) RecordError(_ error) {}
+
+func (s noopSpan) End() {}
+
+// traceStatement returns the statement to record on a span according to the log redaction level. When the
+// redaction level is full then the statement is not recorded.
+func traceStatement(statement string, level LogRedactLevel) (string, bool) {
+	switch level {
+	case RedactNone:
+		return statement, true
+	case RedactPartial:
+		return redactUserDataString(statement), true
+	default:
+		return "", false
+	}
+}
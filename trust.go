@@ -0,0 +1,178 @@
+package cbcolumnar
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// appendPemCertificates parses all of the certificates in the PEM encoded data, adding them to the pool and
+// returning the number of certificates added. An error is returned if any certificate fails to parse, or if
+// the data contains no certificates, in which case the number of certificates added before the failure is
+// also returned.
+func appendPemCertificates(pool *x509.CertPool, data []byte) (int, error) {
+	count := 0
+
+	for {
+		var block *pem.Block
+
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return count, fmt.Errorf("failed to parse certificate %d: %w", count+1, err)
+		}
+
+		pool.AddCert(cert)
+		count++
+	}
+
+	if count == 0 {
+		return 0, errors.New("no certificates found") // nolint: err113
+	}
+
+	return count, nil
+}
+
+// describeTrustOnly returns a description of the trust source for use in errors and logging.
+func describeTrustOnly(trustOnly TrustOnly) string {
+	switch to := trustOnly.(type) {
+	case TrustOnlyCapella:
+		return "TrustOnlyCapella"
+	case TrustOnlySystem:
+		return "TrustOnlySystem"
+	case TrustOnlyPemFile:
+		return fmt.Sprintf("TrustOnlyPemFile (%s)", to.Path)
+	case TrustOnlyPemString:
+		return "TrustOnlyPemString"
+	case TrustOnlyCertificates:
+		return "TrustOnlyCertificates"
+	case TrustOnlyCombined:
+		return "TrustOnlyCombined"
+	default:
+		return fmt.Sprintf("%T", trustOnly)
+	}
+}
+
+func newTrustOnlyError(trustOnly TrustOnly, count int, err error) error {
+	return invalidArgumentError{
+		ArgumentName: "TrustOnly",
+		Reason: fmt.Sprintf("failed to load certificates from %s, %d certificates were loaded: %s",
+			describeTrustOnly(trustOnly), count, err),
+	}
+}
+
+// flattenTrustOnly expands any nested TrustOnlyCombined into the trust sources that it contains.
+func flattenTrustOnly(sources []TrustOnly) []TrustOnly {
+	var flattened []TrustOnly
+
+	for _, source := range sources {
+		if combined, ok := source.(TrustOnlyCombined); ok {
+			flattened = append(flattened, flattenTrustOnly(combined.Sources)...)
+
+			continue
+		}
+
+		flattened = append(flattened, source)
+	}
+
+	return flattened
+}
+
+// appendTrustedCertificates adds the certificates from the trust source to the pool, returning the number of
+// certificates added. TrustOnlySystem, TrustOnlyCertificates and TrustOnlyCombined must be handled by the caller.
+func appendTrustedCertificates(pool *x509.CertPool, trustOnly TrustOnly, logger *clusterLogger) (int, error) {
+	var count int
+
+	var err error
+
+	switch to := trustOnly.(type) {
+	case TrustOnlyCapella:
+		count, err = appendPemCertificates(pool, capellaRootCA)
+	case TrustOnlyPemFile:
+		var data []byte
+
+		data, err = os.ReadFile(to.Path)
+		if err != nil {
+			err = fmt.Errorf("failed to read pem file %w", err)
+
+			break
+		}
+
+		count, err = appendPemCertificates(pool, data)
+	case TrustOnlyPemString:
+		count, err = appendPemCertificates(pool, []byte(to.Pem))
+	default:
+		err = fmt.Errorf("unsupported trust source %T", trustOnly) // nolint: err113
+	}
+
+	if err != nil {
+		return 0, newTrustOnlyError(trustOnly, count, err)
+	}
+
+	logger.Debugf("Loaded %d trusted certificates from %s", count, describeTrustOnly(trustOnly))
+
+	return count, nil
+}
+
+// newCombinedCertPool creates a pool containing the certificates from all of the trust sources.
+func newCombinedCertPool(combined TrustOnlyCombined, logger *clusterLogger) (*x509.CertPool, error) {
+	sources := flattenTrustOnly(combined.Sources)
+	if len(sources) == 0 {
+		return nil, invalidArgumentError{
+			ArgumentName: "TrustOnly",
+			Reason:       "TrustOnlyCombined must contain at least one trust source",
+		}
+	}
+
+	pool := x509.NewCertPool()
+
+	for _, source := range sources {
+		if _, ok := source.(TrustOnlySystem); ok {
+			// The system pool cannot be added to another pool, so it must form the base of the combined pool.
+			systemPool, err := x509.SystemCertPool()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read system cert pool %w", err)
+			}
+
+			pool = systemPool
+
+			break
+		}
+	}
+
+	for _, source := range sources {
+		switch to := source.(type) {
+		case TrustOnlySystem:
+			continue
+		case TrustOnlyCertificates:
+			return nil, invalidArgumentError{
+				ArgumentName: "TrustOnly",
+				Reason:       "TrustOnlyCertificates cannot be combined with other trust sources",
+			}
+		case TrustOnlyPemFile:
+			if to.ReloadInterval > 0 {
+				return nil, invalidArgumentError{
+					ArgumentName: "TrustOnly",
+					Reason:       "TrustOnlyPemFile with a ReloadInterval cannot be combined with other trust sources",
+				}
+			}
+		}
+
+		_, err := appendTrustedCertificates(pool, source, logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pool, nil
+}